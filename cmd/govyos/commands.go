@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"time"
+
+	"github.com/ganawaj/go-vyos/vyos"
+)
+
+// dispatch runs the command in args.
+func dispatch(ctx context.Context, opts *globalOptions, args []string, stdout io.Writer) error {
+
+	out, err := newPrinter(opts.output, stdout)
+	if err != nil {
+		return err
+	}
+
+	cmd, args := args[0], args[1:]
+
+	c, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "show":
+		return runOutput(ctx, args, out, c.Show.DoPath)
+
+	case "generate":
+		return runOutput(ctx, args, out, c.Gen.DoPath)
+
+	case "reset":
+		return runOutput(ctx, args, out, c.Reset.DoPath)
+
+	case "config":
+		return runConfig(ctx, c, args, out)
+
+	case "commit":
+		fs := newFlagSet("commit")
+		confirm := fs.Int("confirm", 0, "revert unless confirmed within `minutes`")
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		if *confirm > 0 {
+			return out.result(c.Conf.CommitConfirm(ctx, *confirm))
+		}
+		return out.result(c.Conf.Commit(ctx))
+
+	case "confirm":
+		return out.result(c.Conf.Confirm(ctx))
+
+	case "discard":
+		return out.result(c.Conf.Discard(ctx))
+
+	case "save":
+		if len(args) > 1 {
+			return usageError("save [file]")
+		}
+		file := ""
+		if len(args) == 1 {
+			file = args[0]
+		}
+		return out.result(c.ConfigFile.Save(ctx, file))
+
+	case "load":
+		if len(args) != 1 {
+			return usageError("load <file>")
+		}
+		return out.result(c.ConfigFile.Load(ctx, args[0]))
+
+	case "image":
+		return runImage(ctx, c, args, out)
+
+	case "reboot":
+		return runPower(ctx, args, out, "reboot", powerCalls{
+			now: c.Power.Reboot, in: c.Power.RebootIn, at: c.Power.RebootAt, cancel: c.Power.CancelReboot,
+		})
+
+	case "poweroff":
+		return runPower(ctx, args, out, "poweroff", powerCalls{
+			now: c.Power.PowerOff, in: c.Power.PowerOffIn, at: c.Power.PowerOffAt, cancel: c.Power.CancelPowerOff,
+		})
+	}
+
+	return usageError("unknown command %q", cmd)
+}
+
+// runOutput runs a command returning operational mode output.
+func runOutput[T any](ctx context.Context, args []string, out *printer, fn func(context.Context, vyos.Path) (T, *vyos.Response, error)) error {
+
+	if len(args) == 0 {
+		return usageError("missing path")
+	}
+
+	return out.result(fn(ctx, vyos.Path(args)))
+}
+
+// runConfig runs the config subcommands.
+func runConfig(ctx context.Context, c *vyos.Client, args []string, out *printer) error {
+
+	if len(args) == 0 {
+		return usageError("config get|set|delete|comment|exists <path>")
+	}
+
+	sub, path := args[0], vyos.Path(args[1:])
+
+	if sub != "get" && len(path) == 0 {
+		return usageError("config %s: missing path", sub)
+	}
+
+	switch sub {
+	case "get":
+		v, resp, err := c.Conf.GetPath(ctx, path, nil)
+		if err != nil {
+			return err
+		}
+		if out.format == "text" {
+			// Print the tree as `set` commands, like `show configuration commands`,
+			// rooted at the requested path so they can be pasted back.
+			tree, errTree := v.Tree()
+			if errTree == nil {
+				if len(path) > 0 {
+					root := vyos.ConfigTree{}
+					if err := root.Set(path, tree); err != nil {
+						return err
+					}
+					tree = root
+				}
+				return out.lines(tree.Commands())
+			}
+		}
+		return out.result(v, resp, nil)
+
+	case "set":
+		return out.result(c.Conf.SetPath(ctx, path))
+
+	case "delete":
+		return out.result(c.Conf.DeletePath(ctx, path))
+
+	case "comment":
+		return out.result(c.Conf.CommentPath(ctx, path))
+
+	case "exists":
+		v, _, err := c.Conf.ExistsPath(ctx, path)
+		if err != nil {
+			return err
+		}
+		exists, _ := v.Data.(bool)
+		if err := out.value(exists); err != nil {
+			return err
+		}
+		if !exists {
+			return exitCode(exitNotFound)
+		}
+		return nil
+	}
+
+	return usageError("unknown config command %q", sub)
+}
+
+// runImage runs the image subcommands.
+func runImage(ctx context.Context, c *vyos.Client, args []string, out *printer) error {
+
+	if len(args) == 0 {
+		return usageError("image add|delete|set-default|list")
+	}
+
+	sub, args := args[0], args[1:]
+
+	if sub == "list" {
+		images, _, err := c.Image.List(ctx)
+		if err != nil {
+			return err
+		}
+		return out.images(images)
+	}
+
+	if len(args) != 1 {
+		return usageError("image %s: want exactly one argument", sub)
+	}
+
+	switch sub {
+	case "add":
+		return out.result(c.Image.Add(ctx, args[0]))
+	case "delete":
+		return out.result(c.Image.Delete(ctx, args[0]))
+	case "set-default":
+		return out.result(c.Image.SetDefault(ctx, args[0]))
+	}
+
+	return usageError("unknown image command %q", sub)
+}
+
+// powerCalls are the PowerService methods of reboot or poweroff.
+type powerCalls struct {
+	now    func(context.Context) (*vyos.PowerResponse, *vyos.Response, error)
+	in     func(context.Context, int) (*vyos.PowerResponse, *vyos.Response, error)
+	at     func(context.Context, time.Time) (*vyos.PowerResponse, *vyos.Response, error)
+	cancel func(context.Context) (*vyos.PowerResponse, *vyos.Response, error)
+}
+
+// runPower runs reboot or poweroff.
+func runPower(ctx context.Context, args []string, out *printer, name string, calls powerCalls) error {
+
+	fs := newFlagSet(name)
+	in := fs.Int("in", 0, "schedule in `minutes`")
+	at := fs.String("at", "", "schedule at `HH:MM` router time, the next time its clock reads it")
+	cancel := fs.Bool("cancel", false, "cancel a scheduled "+name)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// Check whether -in was given, as an invalid delay must never fall
+	// through to an immediate reboot or poweroff.
+	inSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "in" {
+			inSet = true
+		}
+	})
+
+	switch {
+	case *cancel:
+		return out.result(calls.cancel(ctx))
+	case inSet:
+		v, resp, err := calls.in(ctx, *in)
+		if errors.Is(err, vyos.ErrInvalidDelay) {
+			return usageError("%s -in: %v", name, err)
+		}
+		return out.result(v, resp, err)
+	case *at != "":
+		t, err := parseClock(*at)
+		if err != nil {
+			return err
+		}
+		return out.result(calls.at(ctx, t))
+	}
+
+	return out.result(calls.now(ctx))
+}
+
+// parseClock parses HH:MM into a time without a date, so the router
+// schedules it in its own time zone rather than the client's.
+func parseClock(s string) (time.Time, error) {
+
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return time.Time{}, usageError("invalid time %q, want HH:MM", s)
+	}
+
+	return t, nil
+}
+
+// newFlagSet returns a flag set for a command.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags parses the flags of a command without arguments.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+
+	if err := fs.Parse(args); err != nil {
+		return usageError("%s: %v", fs.Name(), err)
+	}
+
+	if fs.NArg() != 0 {
+		return usageError("%s: unexpected argument %q", fs.Name(), fs.Arg(0))
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+// Command govyos is a command-line client for the VyOS API.
+//
+// Usage:
+//
+//	govyos [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	show <path>                      run an operational mode show command
+//	config get [path]                print the configuration
+//	config set|delete|comment <path> change the configuration
+//	config exists <path>             exit 0 if path exists, 3 otherwise
+//	commit [-confirm minutes]        commit, confirm or discard the changes
+//	confirm | discard
+//	save [file] | load <file>        save or load the configuration file
+//	image add <url> | delete <name> | set-default <name> | list
+//	reboot | poweroff [-in minutes | -at HH:MM | -cancel]
+//	generate <path> | reset <path>   run a generate or reset command
+//
+// The router is selected with -url and -token, the GOVYOS_URL and
+// GOVYOS_TOKEN environment variables, or a profile in the configuration
+// file, in that order of precedence. Output is written as text, JSON, YAML
+// or a table with -o.
+//
+// govyos exits with status 1 if the request fails, including an API reply
+// of `success: false`, and 2 for usage errors.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// Exit codes.
+const (
+	exitOK       = 0 // Success.
+	exitError    = 1 // The request failed.
+	exitUsage    = 2 // Invalid flags or arguments.
+	exitNotFound = 3 // config exists: the path does not exist.
+)
+
+// errUsage marks errors caused by invalid flags or arguments.
+var errUsage = errors.New("usage")
+
+// usageError returns an error that exits with exitUsage.
+func usageError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// exitCode is an error that exits with a specific code without a message.
+type exitCode int
+
+func (e exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func main() {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs govyos with args and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+
+	fs := flag.NewFlagSet("govyos", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var opts globalOptions
+	opts.register(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: govyos [flags] <command> [arguments]")
+		fmt.Fprintln(stderr, "\ncommands: show, config, commit, confirm, discard, save, load, image, reboot, poweroff, generate, reset")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	err := dispatch(ctx, &opts, fs.Args(), stdout)
+
+	var code exitCode
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &code):
+		return int(code)
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "govyos: %v\n", err)
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "govyos: %v\n", err)
+		return exitError
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ganawaj/go-vyos/vyos/vyostest"
+)
+
+// TestRun tests commands against a fake router.
+func TestRun(t *testing.T) {
+
+	t.Parallel()
+	srv := vyostest.NewServer()
+	defer srv.Close()
+
+	global := []string{"-config", filepath.Join(t.TempDir(), "none.yaml"), "-url", srv.URL, "-token", srv.Key}
+
+	// A missing explicit configuration file is an error.
+	if code := run(context.TODO(), append(global, "show", "version"), &bytes.Buffer{}, &bytes.Buffer{}); code != exitError {
+		t.Fatalf("run with a missing configuration file exited %v, want %v", code, exitError)
+	}
+
+	global = []string{"-url", srv.URL, "-token", srv.Key}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+	}{
+		{"set", []string{"config", "set", "system", "host-name", "r1"}, exitOK, ""},
+		{"get", []string{"config", "get", "system"}, exitOK, "set system host-name 'r1'\n"},
+		{"get json", []string{"-o", "json", "config", "get", "system"}, exitOK, "{\n  \"host-name\": \"r1\"\n}\n"},
+		{"get yaml", []string{"-o", "yaml", "config", "get", "system"}, exitOK, "host-name: r1\n"},
+		{"exists", []string{"config", "exists", "system", "host-name"}, exitOK, "true\n"},
+		{"not exists", []string{"config", "exists", "system", "domain-name"}, exitNotFound, "false\n"},
+		{"delete missing", []string{"config", "delete", "system", "domain-name"}, exitError, ""},
+		{"show", []string{"show", "version"}, exitOK, "Version:          VyOS 1.4.0\nRelease train:    current\n"},
+		{"image list", []string{"-o", "table", "image", "list"}, exitOK, "NAME   DEFAULT  RUNNING\n1.4.0  yes      yes\n"},
+		{"reboot in zero", []string{"reboot", "-in", "0"}, exitUsage, ""},
+		{"reboot in negative", []string{"reboot", "-in", "-5"}, exitUsage, ""},
+		{"reboot in", []string{"reboot", "-in", "10"}, exitOK, ""},
+		{"poweroff at", []string{"poweroff", "-at", "23:30"}, exitOK, ""},
+		{"poweroff at invalid", []string{"poweroff", "-at", "25:00"}, exitUsage, ""},
+		{"unknown command", []string{"frobnicate"}, exitUsage, ""},
+		{"bad format", []string{"-o", "xml", "show", "version"}, exitUsage, ""},
+	}
+
+	// The commands share one router, so they run in order.
+	for _, tt := range tests {
+
+		var stdout, stderr bytes.Buffer
+		code := run(context.TODO(), append(append([]string{}, global...), tt.args...), &stdout, &stderr)
+
+		if code != tt.wantCode {
+			t.Errorf("%s exited %v, want %v (stderr: %s)", tt.name, code, tt.wantCode, stderr.String())
+		}
+
+		if got := stdout.String(); got != tt.wantOut {
+			t.Errorf("%s output is %q, want %q", tt.name, got, tt.wantOut)
+		}
+	}
+
+	if got, want := srv.Scheduled("/reboot").String(), "in 10"; got != want {
+		t.Errorf("scheduled reboot is %v, want %v", got, want)
+	}
+
+	if got, want := srv.Scheduled("/poweroff").String(), "at 23:30"; got != want {
+		t.Errorf("scheduled poweroff is %v, want %v", got, want)
+	}
+
+	// An invalid delay must not reboot the router.
+	if got := srv.Reboots(); got != 0 {
+		t.Errorf("reboots are %v, want %v", got, 0)
+	}
+}
+
+// TestProfile tests selecting a router from the configuration file.
+func TestProfile(t *testing.T) {
+
+	t.Parallel()
+	srv := vyostest.NewServer()
+	defer srv.Close()
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(config, []byte("default: lab\nprofiles:\n  lab:\n    url: "+srv.URL+"\n    token: "+srv.Key+"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.TODO(), []string{"-config", config, "show", "version"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("run exited %v, want %v (stderr: %s)", code, exitOK, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "VyOS 1.4.0") {
+		t.Errorf("output is %q, want the version", stdout.String())
+	}
+
+	if code := run(context.TODO(), []string{"-config", config, "-profile", "prod", "show", "version"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("run with an unknown profile exited %v, want %v", code, exitUsage)
+	}
+}
+
+// TestUsageHidesToken tests that the API key from the environment is used
+// but never printed in the usage output.
+func TestUsageHidesToken(t *testing.T) {
+
+	srv := vyostest.NewServer()
+	defer srv.Close()
+
+	t.Setenv("GOVYOS_URL", srv.URL)
+	t.Setenv("GOVYOS_TOKEN", srv.Key)
+
+	config := []string{"-config", filepath.Join(t.TempDir(), "none.yaml")}
+	os.WriteFile(config[1], nil, 0o600)
+
+	for _, args := range [][]string{{"-h"}, {"-bogus"}, {}} {
+
+		var stdout, stderr bytes.Buffer
+		run(context.TODO(), append(append([]string{}, config...), args...), &stdout, &stderr)
+
+		if strings.Contains(stderr.String(), srv.Key) {
+			t.Errorf("run %v printed the API key:\n%s", args, stderr.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.TODO(), append(config, "show", "version"), &stdout, &stderr); code != exitOK {
+		t.Errorf("run with the environment exited %v, want %v (stderr: %s)", code, exitOK, stderr.String())
+	}
+}
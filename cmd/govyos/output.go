@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ganawaj/go-vyos/vyos"
+)
+
+// printer writes command results in the selected format.
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+// newPrinter returns a printer for format.
+func newPrinter(format string, w io.Writer) (*printer, error) {
+
+	switch format {
+	case "text", "json", "yaml", "table":
+		return &printer{format: format, w: w}, nil
+	}
+
+	return nil, usageError("unknown output format %q", format)
+}
+
+// result prints the data of an API response, or returns err. v is one of the
+// service response types, all of which embed *vyos.RawResponse.
+func (p *printer) result(v interface{}, resp *vyos.Response, err error) error {
+
+	if err != nil {
+		return err
+	}
+
+	// Re-decode the response to reach the embedded RawResponse.
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var raw vyos.RawResponse
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	return p.value(raw.Data)
+}
+
+// value prints a value.
+func (p *printer) value(v interface{}) error {
+
+	switch p.format {
+	case "json":
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+
+	case "yaml":
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(b)
+		return err
+
+	case "table":
+		if node, ok := v.(map[string]interface{}); ok {
+			return p.tree(vyos.ConfigTree(node))
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		_, err := io.WriteString(p.w, strings.TrimRight(v, "\n")+"\n")
+		return err
+	case []interface{}:
+		for _, item := range v {
+			if _, err := fmt.Fprintln(p.w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		return p.lines(vyos.ConfigTree(v).Commands())
+	}
+
+	_, err := fmt.Fprintln(p.w, v)
+	return err
+}
+
+// lines prints lines of text, or a list in the structured formats.
+func (p *printer) lines(lines []string) error {
+
+	if p.format == "json" || p.format == "yaml" {
+		return p.value(lines)
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(p.w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tree prints a configuration tree as a table of paths and values.
+func (p *printer) tree(t vyos.ConfigTree) error {
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tVALUE")
+
+	err := t.Walk(func(path vyos.Path, value interface{}) error {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if len(v) == 0 {
+				fmt.Fprintf(tw, "%s\t\n", path)
+			}
+		case []interface{}:
+			for _, item := range v {
+				fmt.Fprintf(tw, "%s\t%v\n", path, item)
+			}
+		default:
+			fmt.Fprintf(tw, "%s\t%v\n", path, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Flush()
+}
+
+// images prints installed images.
+func (p *printer) images(images []vyos.Image) error {
+
+	if p.format == "json" || p.format == "yaml" {
+		if images == nil {
+			images = []vyos.Image{}
+		}
+		// Round trip through JSON so YAML uses the same field names.
+		b, err := json.Marshal(images)
+		if err != nil {
+			return err
+		}
+		var v interface{}
+		json.Unmarshal(b, &v)
+		return p.value(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tDEFAULT\tRUNNING")
+	for _, img := range images {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", img.Name, yesNo(img.Default), yesNo(img.Running))
+	}
+
+	return tw.Flush()
+}
+
+// yesNo formats a bool for tables.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
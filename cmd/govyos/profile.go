@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ganawaj/go-vyos/vyos"
+)
+
+// Profile holds the connection settings for a router.
+type Profile struct {
+	URL      string `yaml:"url"`
+	Token    string `yaml:"token"`
+	TokenEnv string `yaml:"token_env"` // Environment variable holding the token.
+	Insecure bool   `yaml:"insecure"`
+}
+
+// ConfigFile is the govyos configuration file:
+//
+//	default: lab
+//	profiles:
+//	  lab:
+//	    url: https://192.168.0.1
+//	    token_env: VYOS_LAB_TOKEN
+//	    insecure: true
+type ConfigFile struct {
+	Default  string             `yaml:"default"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// globalOptions are the flags accepted before the command.
+type globalOptions struct {
+	config   string
+	profile  string
+	url      string
+	token    string
+	insecure bool
+	output   string
+}
+
+// register registers the flags on fs.
+func (o *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.config, "config", "", "configuration `file` (default $XDG_CONFIG_HOME/govyos/config.yaml)")
+	fs.StringVar(&o.profile, "profile", "", "profile `name` from the configuration file (default $GOVYOS_PROFILE)")
+	fs.StringVar(&o.url, "url", "", "router API `url`, e.g. https://192.168.0.1 (default $GOVYOS_URL)")
+	fs.StringVar(&o.token, "token", "", "API `key` (default $GOVYOS_TOKEN)")
+	fs.BoolVar(&o.insecure, "insecure", false, "skip TLS certificate verification")
+	fs.StringVar(&o.output, "o", "text", "output `format`: text, json, yaml or table")
+}
+
+// client returns a client for the selected router.
+func (o *globalOptions) client() (*vyos.Client, error) {
+
+	p, err := o.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.URL == "" {
+		return nil, usageError("no router selected: use -url, GOVYOS_URL or a profile")
+	}
+
+	c := vyos.NewClient(nil)
+	if p.Insecure {
+		c = c.Insecure()
+	}
+
+	return c.WithURL(strings.TrimSuffix(p.URL, "/")).WithToken(p.Token), nil
+}
+
+// resolve merges the profile with the environment and the flags, each
+// taking precedence over the one before. The environment is read here
+// rather than used as flag defaults, so usage output never shows the key.
+func (o *globalOptions) resolve() (Profile, error) {
+
+	var p Profile
+
+	cfg, err := loadConfigFile(o.config)
+	if err != nil {
+		return p, err
+	}
+
+	name := first(o.profile, os.Getenv("GOVYOS_PROFILE"), cfg.Default)
+
+	if name != "" {
+		var ok bool
+		if p, ok = cfg.Profiles[name]; !ok {
+			return p, usageError("profile %q not found", name)
+		}
+		if p.TokenEnv != "" {
+			p.Token = os.Getenv(p.TokenEnv)
+		}
+	}
+
+	p.URL = first(o.url, os.Getenv("GOVYOS_URL"), p.URL)
+	p.Token = first(o.token, os.Getenv("GOVYOS_TOKEN"), p.Token)
+	if o.insecure {
+		p.Insecure = true
+	}
+
+	return p, nil
+}
+
+// first returns the first non-empty value.
+func first(values ...string) string {
+
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// loadConfigFile reads the configuration file. A missing file at the default
+// location is not an error.
+func loadConfigFile(path string) (*ConfigFile, error) {
+
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return &ConfigFile{}, nil
+		}
+		path = filepath.Join(dir, "govyos", "config.yaml")
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return &ConfigFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &ConfigFile{}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,137 @@
+package vyos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+var (
+	ErrNoKeySource = errors.New("no API key source")
+)
+
+// Authenticator adds credentials to requests for the VyOS API.
+//
+// Authenticate is called by NewRequestWithContext before the request body is
+// encoded. fields holds the form fields sent in the body, `data` being
+// already set, and req can be used to set headers.
+type Authenticator interface {
+	Authenticate(ctx context.Context, req *http.Request, fields url.Values) error
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as
+// an Authenticator.
+type AuthenticatorFunc func(ctx context.Context, req *http.Request, fields url.Values) error
+
+// Authenticate calls f(ctx, req, fields).
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, req *http.Request, fields url.Values) error {
+	return f(ctx, req, fields)
+}
+
+// KeySource returns the API key to use for a request. It is called for every
+// request, so it can look the key up in a secret store or rotate it.
+type KeySource func(ctx context.Context) (string, error)
+
+// StaticKey returns a KeySource that always returns key.
+func StaticKey(key string) KeySource {
+	return func(context.Context) (string, error) {
+		return key, nil
+	}
+}
+
+// FormKeyAuth sends the API key in the `key` form field. This is how the
+// VyOS API expects the key and is the default used with WithToken.
+type FormKeyAuth struct {
+	Key KeySource // Source of the API key.
+}
+
+// Authenticate sets the `key` form field.
+func (a FormKeyAuth) Authenticate(ctx context.Context, req *http.Request, fields url.Values) error {
+
+	key, err := lookupKey(ctx, a.Key)
+	if err != nil {
+		return err
+	}
+
+	fields.Set("key", key)
+
+	return nil
+}
+
+// HeaderKeyAuth sends the API key in an HTTP header, e.g. for an API gateway
+// or reverse proxy in front of the router that checks the key itself.
+type HeaderKeyAuth struct {
+	Header string    // Header name, e.g. "Authorization" or "X-API-Key".
+	Prefix string    // Optional prefix of the header value, e.g. "Bearer ".
+	Key    KeySource // Source of the API key.
+}
+
+// Authenticate sets the header.
+func (a HeaderKeyAuth) Authenticate(ctx context.Context, req *http.Request, fields url.Values) error {
+
+	key, err := lookupKey(ctx, a.Key)
+	if err != nil {
+		return err
+	}
+
+	header := a.Header
+	if header == "" {
+		header = "Authorization"
+	}
+
+	req.Header.Set(header, a.Prefix+key)
+
+	return nil
+}
+
+// WithAuthenticator sets the authenticator for the VyOS API client. It takes
+// precedence over the Token field.
+func (c *Client) WithAuthenticator(auth Authenticator) *Client {
+	newClient := c.copy()
+	defer newClient.init()
+
+	// Set the authenticator for the API client.
+	newClient.auth = auth
+
+	return newClient
+}
+
+// authContextKey is the context key used to override the authenticator for a
+// single request.
+type authContextKey struct{}
+
+// ContextWithAuthenticator returns a copy of ctx that makes requests created
+// with it use auth instead of the client's authenticator.
+func ContextWithAuthenticator(ctx context.Context, auth Authenticator) context.Context {
+	return context.WithValue(ctx, authContextKey{}, auth)
+}
+
+// authenticator returns the authenticator for a request created with ctx.
+func (c *Client) authenticator(ctx context.Context) Authenticator {
+
+	if auth, ok := ctx.Value(authContextKey{}).(Authenticator); ok && auth != nil {
+		return auth
+	}
+
+	if c.auth != nil {
+		return c.auth
+	}
+
+	return FormKeyAuth{Key: StaticKey(c.Token)}
+}
+
+// lookupKey returns the key from source.
+func lookupKey(ctx context.Context, source KeySource) (string, error) {
+
+	if source == nil {
+		return "", ErrNoKeySource
+	}
+
+	key, err := source(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
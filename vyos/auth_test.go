@@ -0,0 +1,103 @@
+package vyos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAuthenticator tests the different ways of sending the API key.
+func TestAuthenticator(t *testing.T) {
+
+	t.Parallel()
+
+	var rotations int
+	rotating := KeySource(func(ctx context.Context) (string, error) {
+		rotations++
+		return fmt.Sprintf("key-%d", rotations), nil
+	})
+
+	tests := []struct {
+		name       string
+		client     func(c *Client) *Client
+		ctx        func(ctx context.Context) context.Context
+		wantKey    string
+		wantHeader string
+	}{
+		{
+			name:    "token",
+			client:  func(c *Client) *Client { return c.WithToken("test") },
+			wantKey: "test",
+		},
+		{
+			name:    "key source",
+			client:  func(c *Client) *Client { return c.WithAuthenticator(FormKeyAuth{Key: rotating}) },
+			wantKey: "key-1",
+		},
+		{
+			name: "header",
+			client: func(c *Client) *Client {
+				return c.WithAuthenticator(HeaderKeyAuth{Header: "X-API-Key", Key: StaticKey("secret")})
+			},
+			wantHeader: "secret",
+		},
+		{
+			name:   "context override",
+			client: func(c *Client) *Client { return c.WithToken("test") },
+			ctx: func(ctx context.Context) context.Context {
+				return ContextWithAuthenticator(ctx, FormKeyAuth{Key: StaticKey("override")})
+			},
+			wantKey: "override",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var gotKey, gotHeader string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotKey = r.FormValue("key")
+				gotHeader = r.Header.Get("X-API-Key")
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"success": true, "error": null, "data": ""}`))
+			}))
+			defer srv.Close()
+
+			ctx := context.TODO()
+			if tt.ctx != nil {
+				ctx = tt.ctx(ctx)
+			}
+
+			c := tt.client(NewClient(nil).WithURL(srv.URL))
+			if _, _, err := c.Show.Do(ctx, "version"); err != nil {
+				t.Fatalf("Show.Do returned error: %v", err)
+			}
+
+			if got, want := gotKey, tt.wantKey; got != want {
+				t.Errorf("key is %v, want %v", got, want)
+			}
+
+			if got, want := gotHeader, tt.wantHeader; got != want {
+				t.Errorf("X-API-Key is %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// TestAuthenticatorError tests that key lookup failures are returned.
+func TestAuthenticatorError(t *testing.T) {
+
+	t.Parallel()
+
+	errVault := errors.New("vault sealed")
+	c := NewClient(nil).WithURL("http://127.0.0.1").WithAuthenticator(FormKeyAuth{
+		Key: func(ctx context.Context) (string, error) { return "", errVault },
+	})
+
+	if _, _, err := c.Show.Do(context.TODO(), "version"); !errors.Is(err, errVault) {
+		t.Errorf("Show.Do returned %v, want %v", err, errVault)
+	}
+}
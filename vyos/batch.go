@@ -0,0 +1,129 @@
+package vyos
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrEmptyBatch = errors.New("batch must contain at least one operation")
+)
+
+// ConfigBatch accumulates set, delete and comment operations, in order, and
+// sends them to the /configure endpoint as a single request, so they are
+// applied in one commit.
+type ConfigBatch struct {
+	s        *ConfigService
+	requests []Request
+	err      error // First error encountered while building the batch.
+}
+
+// Batch returns a new, empty configuration batch.
+func (s *ConfigService) Batch() *ConfigBatch {
+	return &ConfigBatch{s: s}
+}
+
+// Set adds a set operation to the batch.
+func (b *ConfigBatch) Set(path string) *ConfigBatch {
+	return b.parse(OPModeSet, path)
+}
+
+// Delete adds a delete operation to the batch.
+func (b *ConfigBatch) Delete(path string) *ConfigBatch {
+	return b.parse(OPModeDelete, path)
+}
+
+// Comment adds a comment operation to the batch.
+func (b *ConfigBatch) Comment(path string) *ConfigBatch {
+	return b.parse(OPModeComment, path)
+}
+
+// SetPath adds a set operation for a structured Path to the batch.
+func (b *ConfigBatch) SetPath(path Path) *ConfigBatch {
+	return b.add(OPModeSet, path)
+}
+
+// DeletePath adds a delete operation for a structured Path to the batch.
+func (b *ConfigBatch) DeletePath(path Path) *ConfigBatch {
+	return b.add(OPModeDelete, path)
+}
+
+// CommentPath adds a comment operation for a structured Path to the batch.
+func (b *ConfigBatch) CommentPath(path Path) *ConfigBatch {
+	return b.add(OPModeComment, path)
+}
+
+// Add adds requests, such as those returned by ParseCommands, to the batch.
+// Only set, delete and comment operations are accepted.
+func (b *ConfigBatch) Add(requests ...Request) *ConfigBatch {
+
+	for _, r := range requests {
+		switch r.OPMode {
+		case OPModeSet, OPModeDelete, OPModeComment:
+			b.add(r.OPMode, r.Path)
+		default:
+			b.fail(ErrMethodNotSupported)
+		}
+	}
+
+	return b
+}
+
+// Requests returns a copy of the operations accumulated so far.
+func (b *ConfigBatch) Requests() []Request {
+	return append([]Request(nil), b.requests...)
+}
+
+// Len returns the number of operations in the batch.
+func (b *ConfigBatch) Len() int {
+	return len(b.requests)
+}
+
+// Do sends all operations in the batch as a single /configure request.
+func (b *ConfigBatch) Do(ctx context.Context) (*ConfigResponse, *Response, error) {
+
+	if b.err != nil {
+		return nil, nil, b.err
+	}
+
+	if len(b.requests) == 0 {
+		return nil, nil, ErrEmptyBatch
+	}
+
+	return b.s.configure(ctx, b.requests)
+}
+
+// parse parses a CLI style path and appends the operation to the batch.
+func (b *ConfigBatch) parse(op OPMode, path string) *ConfigBatch {
+
+	p, err := ParsePath(path)
+	if err != nil {
+		b.fail(err)
+		return b
+	}
+
+	return b.add(op, p)
+}
+
+// add appends an operation to the batch, recording the first invalid path.
+func (b *ConfigBatch) add(op OPMode, path Path) *ConfigBatch {
+
+	if len(path) == 0 {
+		b.fail(ErrEmptyPath)
+		return b
+	}
+
+	b.requests = append(b.requests, Request{
+		OPMode: op,
+		Path:   path,
+	})
+
+	return b
+}
+
+// fail records err unless an earlier error has already been recorded.
+func (b *ConfigBatch) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
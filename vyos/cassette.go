@@ -0,0 +1,292 @@
+package vyos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrCassetteMiss = errors.New("no recorded interaction matches the request")
+	ErrCassetteBody = errors.New("request body cannot be read again without GetBody")
+)
+
+// CassetteMode selects whether a cassette transport records or replays.
+type CassetteMode string
+
+// CassetteMode constants
+const (
+	CassetteRecord CassetteMode = "record" // CassetteRecord sends requests and records the responses.
+	CassetteReplay CassetteMode = "replay" // CassetteReplay answers requests from the recorded responses.
+)
+
+// Interaction is a recorded request and response. The API key is never
+// recorded, whether it was sent in the `key` field or a header.
+type Interaction struct {
+	Endpoint string          `json:"endpoint"` // Endpoint, e.g. /retrieve.
+	Data     json.RawMessage `json:"data"`     // Decoded `data` field of the request.
+
+	StatusCode  int             `json:"status_code"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"` // Response body if it is JSON.
+	Text        string          `json:"text,omitempty"` // Response body otherwise.
+}
+
+// Cassette is a list of recorded interactions. It is safe for concurrent
+// use.
+type Cassette struct {
+	mu           sync.Mutex
+	Interactions []Interaction `json:"interactions"`
+	used         []bool
+	last         map[string]int
+}
+
+// LoadCassette reads a cassette written by Cassette.Save.
+func LoadCassette(path string) (*Cassette, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cassette{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("cannot read cassette %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Save writes the cassette to path as indented JSON.
+func (c *Cassette) Save(path string) error {
+
+	c.mu.Lock()
+	b, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// WithCassette returns a client whose requests are recorded to, or replayed
+// from, the cassette. Requests are matched on the endpoint and the decoded
+// `data` field, so the key and the request encoding do not matter.
+func (c *Client) WithCassette(cassette *Cassette, mode CassetteMode) *Client {
+	newClient := c.copy()
+	defer newClient.init()
+
+	// Wrap the transport of the API client.
+	newClient.client.Transport = &CassetteTransport{
+		Cassette:  cassette,
+		Mode:      mode,
+		Transport: newClient.client.Transport,
+	}
+
+	return newClient
+}
+
+// CassetteTransport is an http.RoundTripper recording to, or replaying
+// from, a cassette.
+type CassetteTransport struct {
+	Cassette  *Cassette
+	Mode      CassetteMode
+	Transport http.RoundTripper // Transport used when recording. Defaults to http.DefaultTransport.
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *CassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	data, err := requestData(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Mode == CassetteReplay {
+		in, ok := t.Cassette.match(req.URL.Path, data)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s", ErrCassetteMiss, req.URL.Path, data)
+		}
+		return in.response(req), nil
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	in := Interaction{
+		Endpoint:    req.URL.Path,
+		Data:        data,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if json.Valid(body) {
+		in.Body = json.RawMessage(bytes.TrimSpace(body))
+	} else {
+		in.Text = string(body)
+	}
+
+	t.Cassette.record(in)
+
+	return resp, nil
+}
+
+// record appends an interaction.
+func (c *Cassette) record(in Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, in)
+}
+
+// match returns the first unused interaction matching the request, in
+// recording order. Once all matching interactions are used, the last one is
+// repeated, e.g. for polling.
+func (c *Cassette) match(endpoint string, data json.RawMessage) (Interaction, bool) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.used) != len(c.Interactions) {
+		c.used = make([]bool, len(c.Interactions))
+		c.last = make(map[string]int)
+	}
+
+	// Compare the decoded data, so key order and spacing do not matter.
+	var want interface{}
+	json.Unmarshal(data, &want)
+	canonical, _ := json.Marshal(want)
+	key := endpoint + " " + string(canonical)
+
+	for i, in := range c.Interactions {
+
+		if c.used[i] || in.Endpoint != endpoint {
+			continue
+		}
+
+		var got interface{}
+		json.Unmarshal(in.Data, &got)
+		if !reflect.DeepEqual(got, want) {
+			continue
+		}
+
+		c.used[i] = true
+		c.last[key] = i
+		return in, true
+	}
+
+	if i, ok := c.last[key]; ok {
+		return c.Interactions[i], true
+	}
+
+	return Interaction{}, false
+}
+
+// response returns the recorded response to req.
+func (in Interaction) response(req *http.Request) *http.Response {
+
+	body := []byte(in.Text)
+	if len(in.Body) > 0 {
+		body = in.Body
+	}
+
+	header := make(http.Header)
+	if in.ContentType != "" {
+		header.Set("Content-Type", in.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		StatusCode:    in.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// requestData returns the `data` field of an API request in any of the
+// supported encodings. The body is read through req.GetBody, as a
+// RoundTripper must not modify the request.
+func requestData(req *http.Request) (json.RawMessage, error) {
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, ErrCassetteBody
+	}
+
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	switch {
+	case mediaType == "application/json":
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(body, &obj); err != nil {
+			return nil, err
+		}
+		delete(obj, "key")
+		if commands, ok := obj["commands"]; ok {
+			return commands, nil
+		}
+		return json.Marshal(obj)
+
+	case strings.HasPrefix(mediaType, "multipart/"):
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(int64(len(body)) + 1)
+		if err != nil {
+			return nil, err
+		}
+		defer form.RemoveAll()
+		if values := form.Value["data"]; len(values) > 0 && values[0] != "" {
+			return json.RawMessage(values[0]), nil
+		}
+		return nil, nil
+
+	default:
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		if data := values.Get("data"); data != "" {
+			return json.RawMessage(data), nil
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,139 @@
+package vyos
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCassette tests recording interactions and replaying them offline.
+func TestCassette(t *testing.T) {
+
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success": true, "error": null, "data": "Version:          VyOS 1.4.0"}`))
+	}))
+
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	// Record against the server.
+	recording := &Cassette{}
+	c := NewClient(nil).WithURL(srv.URL).WithToken("secret").WithCassette(recording, CassetteRecord)
+	if _, _, err := c.Show.Do(ctx, "version"); err != nil {
+		t.Fatalf("Show.Do returned error: %v", err)
+	}
+	srv.Close()
+
+	if err := recording.Save(path); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Error("cassette contains the API key")
+	}
+
+	// Replay without the server, using a different key and encoding.
+	replaying, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette returned error: %v", err)
+	}
+
+	c = NewClient(nil).WithURL(srv.URL).WithToken("other").WithEncoder(JSONEncoder).WithCassette(replaying, CassetteReplay)
+
+	out, _, err := c.Show.Do(ctx, "version")
+	if err != nil {
+		t.Fatalf("replayed Show.Do returned error: %v", err)
+	}
+
+	if got, want := out.Data, "Version:          VyOS 1.4.0"; got != want {
+		t.Errorf("replayed Data is %v, want %v", got, want)
+	}
+
+	if _, _, err := c.Show.Do(ctx, "interfaces"); !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("Show.Do for an unrecorded path returned %v, want %v", err, ErrCassetteMiss)
+	}
+}
+
+// TestCassetteKeptByTLSOptions tests that the TLS options configure the
+// transport wrapped by a cassette instead of replacing the cassette.
+func TestCassetteKeptByTLSOptions(t *testing.T) {
+
+	t.Parallel()
+
+	cassette := &Cassette{}
+	base := NewClient(nil).WithCassette(cassette, CassetteRecord)
+
+	for name, c := range map[string]*Client{
+		"Insecure":      base.Insecure(),
+		"WithTLSConfig": base.WithTLSConfig(&tls.Config{InsecureSkipVerify: true}),
+	} {
+
+		ct, ok := c.client.Transport.(*CassetteTransport)
+		if !ok {
+			t.Errorf("%s transport is %T, want *CassetteTransport", name, c.client.Transport)
+			continue
+		}
+		if ct.Cassette != cassette {
+			t.Errorf("%s changed the cassette", name)
+		}
+
+		inner, ok := ct.Transport.(*http.Transport)
+		if !ok || inner.TLSClientConfig == nil || !inner.TLSClientConfig.InsecureSkipVerify {
+			t.Errorf("%s did not configure the wrapped transport", name)
+		}
+	}
+
+	if base.client.Transport.(*CassetteTransport).Transport != nil {
+		t.Error("the original client transport was changed")
+	}
+}
+
+// TestCassetteTransportRequest tests that RoundTrip leaves the request
+// unchanged.
+func TestCassetteTransportRequest(t *testing.T) {
+
+	t.Parallel()
+
+	cassette := &Cassette{Interactions: []Interaction{{
+		Endpoint:   "/show",
+		Data:       json.RawMessage(`{"op":"show","path":["version"]}`),
+		StatusCode: http.StatusOK,
+		Body:       json.RawMessage(`{"success": true, "error": null, "data": ""}`),
+	}}}
+
+	c := NewClient(nil).WithURL("https://192.0.2.1").WithToken("test")
+	req, err := c.NewRequestWithContext(context.TODO(), "/show", &Request{OPMode: "show", Path: Path{"version"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body
+
+	ct := &CassetteTransport{Cassette: cassette, Mode: CassetteReplay}
+	if _, err := ct.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+
+	if req.Body != body {
+		t.Error("RoundTrip replaced the request body")
+	}
+
+	// Without GetBody the body cannot be read without changing the request.
+	req.GetBody = nil
+	if _, err := ct.RoundTrip(req); !errors.Is(err, ErrCassetteBody) {
+		t.Errorf("RoundTrip without GetBody returned %v, want %v", err, ErrCassetteBody)
+	}
+}
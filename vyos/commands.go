@@ -0,0 +1,176 @@
+package vyos
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CommandError is returned by ParseCommands for an invalid line.
+type CommandError struct {
+	Line int    // Line number, starting at 1.
+	Text string // The offending command.
+	Err  error  // What was wrong with it.
+}
+
+// Error implements the error interface.
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Text, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// skippedCommands are session commands that are accepted in scripts but
+// have no effect, since a batch is committed by the API when it is sent.
+var skippedCommands = map[string]bool{
+	"configure": true,
+	"commit":    true,
+	"save":      true,
+	"exit":      true,
+	"end":       true,
+}
+
+// ParseCommands parses a script of `set`, `delete` and `comment` commands,
+// one per line, into requests that can be sent with ConfigBatch.Add:
+//
+//	# Move eth0 to the new subnet
+//	delete interfaces ethernet eth0 address 192.0.2.1/24
+//	set interfaces ethernet eth0 address '198.51.100.1/24'
+//	comment interfaces ethernet eth0 "Moved to new subnet"
+//
+// Arguments are quoted as in ParsePath. Blank lines and lines starting with
+// # are ignored, a line ending in a backslash continues on the next line, and
+// the session commands configure, commit, save and exit are skipped.
+func ParseCommands(r io.Reader) ([]Request, error) {
+
+	var reqs []Request
+
+	scanner := bufio.NewScanner(r)
+	lineNo, start := 0, 0
+	var line strings.Builder
+
+	for scanner.Scan() {
+
+		lineNo++
+		text := scanner.Text()
+
+		if line.Len() == 0 {
+			start = lineNo
+		}
+
+		// Join continued lines.
+		if strings.HasSuffix(text, `\`) && !strings.HasSuffix(text, `\\`) {
+			line.WriteString(strings.TrimSuffix(text, `\`))
+			line.WriteByte(' ')
+			continue
+		}
+
+		line.WriteString(text)
+		cmd := strings.TrimSpace(line.String())
+		line.Reset()
+
+		if cmd == "" || strings.HasPrefix(cmd, "#") {
+			continue
+		}
+
+		req, skip, err := parseCommand(cmd)
+		if err != nil {
+			return nil, &CommandError{Line: start, Text: cmd, Err: err}
+		}
+
+		if !skip {
+			reqs = append(reqs, req)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if line.Len() > 0 {
+		return nil, &CommandError{Line: start, Text: strings.TrimSpace(line.String()), Err: ErrTrailingEscape}
+	}
+
+	return reqs, nil
+}
+
+// parseCommand parses a single command.
+func parseCommand(cmd string) (Request, bool, error) {
+
+	args, err := ParsePath(cmd)
+	if err != nil {
+		return Request{}, false, err
+	}
+
+	verb := args[0]
+
+	if skippedCommands[verb] && len(args) == 1 {
+		return Request{}, true, nil
+	}
+
+	var op OPMode
+	switch verb {
+	case "set":
+		op = OPModeSet
+	case "delete":
+		op = OPModeDelete
+	case "comment":
+		op = OPModeComment
+	default:
+		return Request{}, false, fmt.Errorf("unknown command %q", verb)
+	}
+
+	if len(args) == 1 {
+		return Request{}, false, ErrEmptyPath
+	}
+
+	return Request{OPMode: op, Path: args[1:]}, false, nil
+}
+
+// Commands returns the tree as `set` commands, in the style of
+// `show configuration commands`: leaf values are single quoted and
+// commands are sorted by path.
+func (t ConfigTree) Commands() []string {
+
+	var cmds []string
+	appendCommands(&cmds, "set", map[string]interface{}(t))
+
+	return cmds
+}
+
+// appendCommands appends the set commands for node under prefix.
+func appendCommands(cmds *[]string, prefix string, node interface{}) {
+
+	if t, ok := node.(ConfigTree); ok {
+		node = map[string]interface{}(t)
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+
+		if len(n) == 0 {
+			*cmds = append(*cmds, prefix)
+			return
+		}
+
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			appendCommands(cmds, prefix+" "+quoteElem(k), n[k])
+		}
+
+	default:
+		for _, v := range leafValues(n) {
+			*cmds = append(*cmds, prefix+" '"+strings.ReplaceAll(v, "'", `'\''`)+"'")
+		}
+	}
+}
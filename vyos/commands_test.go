@@ -0,0 +1,94 @@
+package vyos
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseCommands tests parsing of set command scripts.
+func TestParseCommands(t *testing.T) {
+
+	t.Parallel()
+
+	script := `configure
+# Move eth0 to the new subnet
+delete interfaces ethernet eth0 address 192.0.2.1/24
+
+set interfaces ethernet eth0 address '198.51.100.1/24'
+set interfaces ethernet eth0 description \
+    "Uplink to ISP"
+comment interfaces ethernet eth0 "Moved to new subnet"
+commit
+save
+`
+
+	reqs, err := ParseCommands(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("ParseCommands returned error: %v", err)
+	}
+
+	eth0 := P("interfaces", "ethernet", "eth0")
+	want := []Request{
+		{OPMode: OPModeDelete, Path: eth0.Append("address", "192.0.2.1/24")},
+		{OPMode: OPModeSet, Path: eth0.Append("address", "198.51.100.1/24")},
+		{OPMode: OPModeSet, Path: eth0.Append("description", "Uplink to ISP")},
+		{OPMode: OPModeComment, Path: eth0.Append("Moved to new subnet")},
+	}
+
+	if !reflect.DeepEqual(reqs, want) {
+		t.Errorf("ParseCommands is %v, want %v", reqs, want)
+	}
+
+	_, err = ParseCommands(strings.NewReader("set system host-name r1\nshow interfaces\n"))
+	var cerr *CommandError
+	if !errors.As(err, &cerr) || cerr.Line != 2 {
+		t.Errorf("ParseCommands returned %v, want *CommandError on line 2", err)
+	}
+
+	_, err = ParseCommands(strings.NewReader("set system host-name 'r1\n"))
+	if !errors.Is(err, ErrUnterminatedQuote) {
+		t.Errorf("ParseCommands returned %v, want %v", err, ErrUnterminatedQuote)
+	}
+}
+
+// TestConfigTreeCommands tests flattening a tree into set commands.
+func TestConfigTreeCommands(t *testing.T) {
+
+	t.Parallel()
+
+	tree := ConfigTree{
+		"interfaces": map[string]interface{}{
+			"ethernet": map[string]interface{}{
+				"eth0": map[string]interface{}{
+					"address":     []interface{}{"192.0.2.1/24", "2001:db8::1/64"},
+					"description": "Bob's uplink",
+					"disable":     map[string]interface{}{},
+				},
+			},
+		},
+	}
+
+	want := []string{
+		"set interfaces ethernet eth0 address '192.0.2.1/24'",
+		"set interfaces ethernet eth0 address '2001:db8::1/64'",
+		`set interfaces ethernet eth0 description 'Bob'\''s uplink'`,
+		"set interfaces ethernet eth0 disable",
+	}
+
+	cmds := tree.Commands()
+	if !reflect.DeepEqual(cmds, want) {
+		t.Fatalf("ConfigTree.Commands is %v, want %v", cmds, want)
+	}
+
+	// The output parses back into the same tree.
+	reqs, err := ParseCommands(strings.NewReader(strings.Join(cmds, "\n")))
+	if err != nil {
+		t.Fatalf("ParseCommands returned error: %v", err)
+	}
+
+	if got, want := reqs[2].Path[len(reqs[2].Path)-1], "Bob's uplink"; got != want {
+		t.Errorf("Parsed description is %v, want %v", got, want)
+	}
+}
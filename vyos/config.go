@@ -3,7 +3,6 @@ package vyos
 import (
 	"context"
 	"errors"
-	"strings"
 )
 
 // Response represents a response from the VyOS API.
@@ -19,6 +18,18 @@ type ConfigRequest struct {
 	File   string `json:"file,omitempty"`
 }
 
+// ConfigCommitRequest represents a commit, commit-confirm, confirm or discard request.
+type ConfigCommitRequest struct {
+	OPMode      OPMode `json:"op,omitempty"`
+	ConfirmTime int    `json:"confirm_time,omitempty"` // Minutes before an unconfirmed commit is reverted.
+}
+
+// ConfigRollbackRequest represents a rollback request.
+type ConfigRollbackRequest struct {
+	OPMode   OPMode `json:"op,omitempty"`
+	Revision int    `json:"revision"` // Revision to roll back to, as listed by `show system commit`.
+}
+
 // type RetriveConfigRequest struct {
 // 	OPMode OPMode `json:"op,omitempty"`
 // 	Path   Path `json:"path"`
@@ -30,6 +41,8 @@ type RetrieveOptions struct {
 
 var (
 	ErrMustLoadFromFile = errors.New("file must not be empty or nil")
+	ErrInvalidConfirmTime = errors.New("confirm time must be greater than zero")
+	ErrInvalidRevision = errors.New("revision must not be negative")
 )
 
 // endpoint: /retrive
@@ -48,15 +61,21 @@ var (
 // - op: returnValues, path: []string{} &ShowOptions{multivalue: true}
 func (s *ConfigService) Get(ctx context.Context, path string, options *RetrieveOptions) (*ConfigResponse, *Response, error) {
 
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.GetPath(ctx, p, options)
+}
+
+// GetPath is like Get but takes a structured Path.
+func (s *ConfigService) GetPath(ctx context.Context, path Path, options *RetrieveOptions) (*ConfigResponse, *Response, error) {
+
 	u := "/retrieve"
 
-	var p []string
 	op := "showConfig"
 
-	if path != "" {
-		p = strings.Split(path, " ")
-	}
-
 	// if the options contain MultiValue, then set the op to returnValues
 	if options != nil && options.MultiValue {
 		op = "returnValues"
@@ -65,11 +84,11 @@ func (s *ConfigService) Get(ctx context.Context, path string, options *RetrieveO
 	// Create a new request.
 	request := Request{
 		OPMode: OPMode(op),
-		Path: p,
+		Path: path,
 	}
 
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, &request)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -89,22 +108,27 @@ func (s *ConfigService) Get(ctx context.Context, path string, options *RetrieveO
 // - op: exists, path: []string{}
 func (s *ConfigService) Exists(ctx context.Context, path string) (*ConfigResponse, *Response, error) {
 
-	u := "/retrieve"
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.ExistsPath(ctx, p)
+}
 
-	var p []string
+// ExistsPath is like Exists but takes a structured Path.
+func (s *ConfigService) ExistsPath(ctx context.Context, path Path) (*ConfigResponse, *Response, error) {
 
-	if path != "" {
-		p = strings.Split(path, " ")
-	}
+	u := "/retrieve"
 
 	// Create a new request.
 	request := Request{
 		OPMode: "exists",
-		Path: p,
+		Path: path,
 	}
 
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, &request)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -133,158 +157,146 @@ func (s *ConfigService) Exists(ctx context.Context, path string) (*ConfigRespons
 // NOTE: This does not send multiple requests to the API. It sends a single request with multiple paths.
 func (s *ConfigService) Set(ctx context.Context, path ...string) (*ConfigResponse, *Response, error) {
 
-	u := "/configure"
-
-	var r []Request
-
 	if path == nil {
 		return nil, nil, ErrEmptyPath
 	}
 
-	for _, p := range path {
-
-		if p == "" {
-			return nil, nil, ErrEmptyPath
-		}
+	var paths []Path
 
-		p := strings.Split(p, " ")
-		if p == nil {
-			return nil, nil, ErrEmptyPath
-		}
+	for _, p := range path {
 
-		// Create a new request.
-		request := Request{
-			OPMode: OPMode("set"),
-			Path: p,
+		p, err := ParsePath(p)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		r = append(r, request)
+		paths = append(paths, p)
 	}
 
-	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &r)
-	if err != nil {
-		return nil, nil, err
-	}
+	return s.SetPath(ctx, paths...)
+}
 
-	s.client.mu.Lock()
-	defer s.client.mu.Unlock()
+// SetPath is like Set but takes structured Paths.
+func (s *ConfigService) SetPath(ctx context.Context, path ...Path) (*ConfigResponse, *Response, error) {
 
-	// Create the Response struct & send the request.
-	v := new(ConfigResponse)
-	resp, err := s.client.Do(ctx, req, v)
-	if err != nil {
-		return nil, resp, err
+	if path == nil {
+		return nil, nil, ErrEmptyPath
 	}
 
-	return v, resp, nil
+	b := s.Batch()
+	for _, p := range path {
+		b.SetPath(p)
+	}
 
+	return b.Do(ctx)
 }
 
-
-
 // Delete deletes a configuration path in the VyOS API.
 func (s *ConfigService) Delete(ctx context.Context, path string) (*ConfigResponse, *Response, error) {
 
-	u := "/configure"
-
-	if path == "" {
-		return nil, nil, ErrEmptyPath
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	p := strings.Split(path, " ")
-	if p == nil {
+	return s.DeletePath(ctx, p)
+}
+
+// DeletePath is like Delete but takes a structured Path.
+func (s *ConfigService) DeletePath(ctx context.Context, path Path) (*ConfigResponse, *Response, error) {
+
+	if len(path) == 0 {
 		return nil, nil, ErrEmptyPath
 	}
 
 	// Create a new request.
 	request := Request{
-		OPMode: OPMode("delete"),
-		Path:  p,
+		OPMode: OPModeDelete,
+		Path:  path,
 	}
 
-	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
-	if err != nil {
-		return nil, nil, err
-	}
+	return s.configure(ctx, &request)
+}
 
-	s.client.mu.Lock()
-	defer s.client.mu.Unlock()
+// Comment sets a comment on a configuration node. The last element of the
+// path is the comment text.
+func (s *ConfigService) Comment(ctx context.Context, path string) (*ConfigResponse, *Response, error) {
 
-	// Create the Response struct & send the request.
-	v := new(ConfigResponse)
-	resp, err := s.client.Do(ctx, req, v)
+	p, err := ParsePath(path)
 	if err != nil {
-		return nil, resp, err
+		return nil, nil, err
 	}
 
-	return v, resp, nil
-
+	return s.CommentPath(ctx, p)
 }
 
-func (s *ConfigService) Comment(ctx context.Context, path string) (*ConfigResponse, *Response, error) {
-
-	u := "/configure"
+// CommentPath is like Comment but takes a structured Path.
+func (s *ConfigService) CommentPath(ctx context.Context, path Path) (*ConfigResponse, *Response, error) {
 
-	if path == "" {
+	if len(path) == 0 {
 		return nil, nil, ErrEmptyPath
 	}
 
-	p := strings.Split(path, " ")
-	if p == nil {
-		return nil, nil, ErrEmptyPath
+	// Create a new request.
+	request := Request{
+		OPMode: OPModeComment,
+		Path:  path,
 	}
 
+	return s.configure(ctx, &request)
+}
+
+// Add adds a new image from a url
+func (s *ConfigService) Save(ctx context.Context, file string) (*ConfigResponse, *Response, error) {
+
+	u := "/config-file"
+
 	// Create a new request.
-	request := Request{
-		OPMode: OPMode("comment"),
-		Path:  p,
+	request := ConfigRequest{
+		OPMode: "save",
+		File: file,
 	}
 
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, &request)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	s.client.mu.Lock()
-	defer s.client.mu.Unlock()
-
 	// Create the Response struct & send the request.
 	v := new(ConfigResponse)
-	resp, err := s.client.Do(ctx, req, v)
+	resp, err := s.client.doLocked(ctx, req, v)
 	if err != nil {
 		return nil, resp, err
 	}
 
 	return v, resp, nil
-
 }
 
 // Add adds a new image from a url
-func (s *ConfigService) Save(ctx context.Context, file string) (*ConfigResponse, *Response, error) {
+func (s *ConfigService) Load(ctx context.Context, file string) (*ConfigResponse, *Response, error) {
 
 	u := "/config-file"
 
+	if file == "" {
+		return nil, nil, ErrMustLoadFromFile
+	}
+
 	// Create a new request.
 	request := ConfigRequest{
-		OPMode: "save",
+		OPMode: "load",
 		File: file,
 	}
 
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, &request)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	s.client.mu.Lock()
-	defer s.client.mu.Unlock()
-
 	// Create the Response struct & send the request.
 	v := new(ConfigResponse)
-	resp, err := s.client.Do(ctx, req, v)
+	resp, err := s.client.doLocked(ctx, req, v)
 	if err != nil {
 		return nil, resp, err
 	}
@@ -292,36 +304,76 @@ func (s *ConfigService) Save(ctx context.Context, file string) (*ConfigResponse,
 	return v, resp, nil
 }
 
-// Add adds a new image from a url
-func (s *ConfigService) Load(ctx context.Context, file string) (*ConfigResponse, *Response, error) {
+// endpoint: /configure
+// method: POST
 
-	u := "/config-file"
+// Options
+// op: commit - To commit the pending configuration
+// op: commit-confirm, confirm_time: int - To commit with an automatic revert window
+// op: confirm - To confirm a pending commit-confirm
+// op: discard - To discard uncommitted changes
+// op: rollback, revision: int - To roll back to a previous revision
+
+// Commit commits the pending configuration.
+func (s *ConfigService) Commit(ctx context.Context) (*ConfigResponse, *Response, error) {
+	return s.configure(ctx, &ConfigCommitRequest{OPMode: OPModeCommit})
+}
 
-	if file == "" {
-		return nil, nil, ErrMustLoadFromFile
+// CommitConfirm commits the pending configuration and reverts it automatically
+// unless Confirm is called within the given number of minutes.
+func (s *ConfigService) CommitConfirm(ctx context.Context, minutes int) (*ConfigResponse, *Response, error) {
+
+	if minutes <= 0 {
+		return nil, nil, ErrInvalidConfirmTime
 	}
 
-	// Create a new request.
-	request := ConfigRequest{
-		OPMode: "load",
-		File: file,
+	return s.configure(ctx, &ConfigCommitRequest{
+		OPMode:      OPModeCommitConfirm,
+		ConfirmTime: minutes,
+	})
+}
+
+// Confirm confirms a commit made with CommitConfirm, cancelling the revert.
+func (s *ConfigService) Confirm(ctx context.Context) (*ConfigResponse, *Response, error) {
+	return s.configure(ctx, &ConfigCommitRequest{OPMode: OPModeConfirm})
+}
+
+// Discard discards all uncommitted changes.
+func (s *ConfigService) Discard(ctx context.Context) (*ConfigResponse, *Response, error) {
+	return s.configure(ctx, &ConfigCommitRequest{OPMode: OPModeDiscard})
+}
+
+// Rollback rolls the configuration back to the given revision and reboots
+// the router, as `rollback <revision>` does on the CLI.
+func (s *ConfigService) Rollback(ctx context.Context, revision int) (*ConfigResponse, *Response, error) {
+
+	if revision < 0 {
+		return nil, nil, ErrInvalidRevision
 	}
 
+	return s.configure(ctx, &ConfigRollbackRequest{
+		OPMode:   OPModeRollback,
+		Revision: revision,
+	})
+}
+
+// configure sends a request to the /configure endpoint while holding the client lock.
+func (s *ConfigService) configure(ctx context.Context, request interface{}) (*ConfigResponse, *Response, error) {
+
+	u := "/configure"
+
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, request)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	s.client.mu.Lock()
-	defer s.client.mu.Unlock()
-
 	// Create the Response struct & send the request.
 	v := new(ConfigResponse)
-	resp, err := s.client.Do(ctx, req, v)
+	resp, err := s.client.doLocked(ctx, req, v)
 	if err != nil {
 		return nil, resp, err
 	}
 
 	return v, resp, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,145 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestConfigBatch tests that a batch is sent as a single ordered request.
+func TestConfigBatch(t *testing.T) {
+
+	t.Parallel()
+
+	var got []Request
+	calls := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		calls++
+
+		if r.URL.Path != "/configure" {
+			t.Errorf("Request path is %v, want %v", r.URL.Path, "/configure")
+		}
+
+		if err := json.Unmarshal([]byte(r.FormValue("data")), &got); err != nil {
+			t.Errorf("Error decoding request data: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success": true, "data": null, "error": null}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	_, _, err := c.Conf.Batch().
+		Delete("interfaces dummy dum0 address 10.0.0.1/32").
+		Set("interfaces dummy dum0 address 10.0.0.2/32").
+		Comment("interfaces dummy dum0 moved").
+		Do(context.TODO())
+	if err != nil {
+		t.Fatalf("Batch.Do returned error: %v", err)
+	}
+
+	want := []Request{
+		{OPMode: OPModeDelete, Path: Path{"interfaces", "dummy", "dum0", "address", "10.0.0.1/32"}},
+		{OPMode: OPModeSet, Path: Path{"interfaces", "dummy", "dum0", "address", "10.0.0.2/32"}},
+		{OPMode: OPModeComment, Path: Path{"interfaces", "dummy", "dum0", "moved"}},
+	}
+
+	if calls != 1 {
+		t.Errorf("Batch.Do sent %v requests, want 1", calls)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Batch.Do sent %v, want %v", got, want)
+	}
+}
+
+// TestConfigBatchErrors tests that invalid batches are rejected before sending.
+func TestConfigBatchErrors(t *testing.T) {
+
+	t.Parallel()
+
+	c := NewClient(nil)
+
+	if _, _, err := c.Conf.Batch().Do(context.TODO()); err != ErrEmptyBatch {
+		t.Errorf("Batch.Do returned %v, want %v", err, ErrEmptyBatch)
+	}
+
+	if _, _, err := c.Conf.Batch().Set("system host-name r1").Delete("").Do(context.TODO()); err != ErrEmptyPath {
+		t.Errorf("Batch.Do returned %v, want %v", err, ErrEmptyPath)
+	}
+}
+
+// TestConfigCommit tests the fields sent by the commit operations.
+func TestConfigCommit(t *testing.T) {
+
+	t.Parallel()
+
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.URL.Path != "/configure" {
+			t.Errorf("Request path is %v, want %v", r.URL.Path, "/configure")
+		}
+
+		got = nil
+		if err := json.Unmarshal([]byte(r.FormValue("data")), &got); err != nil {
+			t.Errorf("Error decoding request data: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success": true, "data": null, "error": null}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	ctx := context.TODO()
+
+	tests := []struct {
+		name string
+		call func() (*ConfigResponse, *Response, error)
+		want map[string]interface{}
+	}{
+		{"Commit", func() (*ConfigResponse, *Response, error) { return c.Conf.Commit(ctx) },
+			map[string]interface{}{"op": "commit"}},
+		{"CommitConfirm", func() (*ConfigResponse, *Response, error) { return c.Conf.CommitConfirm(ctx, 5) },
+			map[string]interface{}{"op": "commit-confirm", "confirm_time": float64(5)}},
+		{"Confirm", func() (*ConfigResponse, *Response, error) { return c.Conf.Confirm(ctx) },
+			map[string]interface{}{"op": "confirm"}},
+		{"Discard", func() (*ConfigResponse, *Response, error) { return c.Conf.Discard(ctx) },
+			map[string]interface{}{"op": "discard"}},
+		{"Rollback", func() (*ConfigResponse, *Response, error) { return c.Conf.Rollback(ctx, 0) },
+			map[string]interface{}{"op": "rollback", "revision": float64(0)}},
+		{"Rollback 3", func() (*ConfigResponse, *Response, error) { return c.Conf.Rollback(ctx, 3) },
+			map[string]interface{}{"op": "rollback", "revision": float64(3)}},
+	}
+
+	// The requests share one server, so they run in order.
+	for _, tt := range tests {
+		if _, _, err := tt.call(); err != nil {
+			t.Fatalf("%s returned error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s sent %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Invalid arguments are rejected before sending.
+	got = nil
+	for _, minutes := range []int{0, -1} {
+		if _, _, err := c.Conf.CommitConfirm(ctx, minutes); err != ErrInvalidConfirmTime {
+			t.Errorf("CommitConfirm(%d) returned %v, want %v", minutes, err, ErrInvalidConfirmTime)
+		}
+	}
+	if _, _, err := c.Conf.Rollback(ctx, -1); err != ErrInvalidRevision {
+		t.Errorf("Rollback(-1) returned %v, want %v", err, ErrInvalidRevision)
+	}
+	if got != nil {
+		t.Errorf("invalid arguments sent %v, want no request", got)
+	}
+}
@@ -0,0 +1,368 @@
+// Package configtree reads and writes the VyOS config.boot format, the
+// curly-brace configuration file stored on the router, and converts it to
+// and from the JSON tree shape returned by the /retrieve endpoint.
+//
+// Files written by VyOS round trip byte-for-byte through Parse and Write,
+// including node comments, blank lines and the version footer.
+package configtree
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// indent is the indentation used by VyOS for each level of nesting.
+const indent = "    "
+
+// Config is a parsed config.boot file.
+type Config struct {
+	Nodes   []*Node  // Top level nodes, in file order.
+	Trailer []string // Lines after the last node, such as the version footer.
+}
+
+// Node is a statement in a config.boot file: either a block with children,
+// such as `ethernet eth0 { ... }`, or a leaf, such as `address 192.0.2.1/24`.
+type Node struct {
+	Name     string   // Node name, e.g. ethernet or address.
+	Value    string   // Tag value of a block or value of a leaf.
+	HasValue bool     // Whether Value is set, as it may be empty ("").
+	Quoted   bool     // Whether Value is written in double quotes.
+	Block    bool     // Whether the node is a block with children.
+	Children []*Node  // Children of a block, in file order.
+	Comments []string // Text of the /* */ comments preceding the node.
+
+	raw         []string // Lines preceding the node as parsed, comments included.
+	rawComments []string // Comments as parsed, to detect changes.
+	closing     []string // Blank and // lines before the closing } of a block.
+}
+
+// SyntaxError is returned by Parse for malformed input.
+type SyntaxError struct {
+	Line int    // Line number, starting at 1.
+	Msg  string // Description of the problem.
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Msg)
+}
+
+var versionRegexp = regexp.MustCompile(`(?:vyos|vyatta)-config-version:\s*"([^"]*)"`)
+var releaseRegexp = regexp.MustCompile(`Release version:\s*([^\s*]+)`)
+
+// Parse parses a config.boot file.
+func Parse(r io.Reader) (*Config, error) {
+
+	p := &parser{scanner: bufio.NewScanner(r)}
+	p.scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	return p.parse()
+}
+
+// ParseString parses a config.boot file held in a string.
+func ParseString(s string) (*Config, error) {
+	return Parse(strings.NewReader(s))
+}
+
+// WriteTo writes the configuration in config.boot format.
+func (c *Config) WriteTo(w io.Writer) (int64, error) {
+
+	var b bytes.Buffer
+
+	for _, n := range c.Nodes {
+		n.write(&b, 0)
+	}
+
+	writeLines(&b, c.Trailer)
+
+	return b.WriteTo(w)
+}
+
+// String returns the configuration in config.boot format.
+func (c *Config) String() string {
+
+	var b strings.Builder
+	c.WriteTo(&b)
+
+	return b.String()
+}
+
+// Version returns the config version string from the footer, e.g.
+// "bgp@5:broadcast-relay@1:...", or an empty string if there is none.
+func (c *Config) Version() string {
+
+	for _, line := range c.Trailer {
+		if m := versionRegexp.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+
+	return ""
+}
+
+// Release returns the VyOS release recorded in the footer, e.g. 1.4.0.
+func (c *Config) Release() string {
+
+	for _, line := range c.Trailer {
+		if m := releaseRegexp.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+
+	return ""
+}
+
+// write writes the node and its children at the given depth.
+func (n *Node) write(b *bytes.Buffer, depth int) {
+
+	prefix := strings.Repeat(indent, depth)
+
+	// Write the parsed lines, unless the comments were changed.
+	if n.raw != nil && slices.Equal(n.Comments, n.rawComments) {
+		writeLines(b, n.raw)
+	} else {
+		for _, c := range n.Comments {
+			// Indent continuation lines of multi-line comments with the node.
+			c = strings.ReplaceAll(c, "\n", "\n"+prefix)
+			fmt.Fprintf(b, "%v/* %v */\n", prefix, c)
+		}
+	}
+
+	b.WriteString(prefix)
+	b.WriteString(n.Name)
+
+	if n.HasValue {
+		b.WriteByte(' ')
+		if n.Quoted {
+			b.WriteString(quote(n.Value))
+		} else {
+			b.WriteString(n.Value)
+		}
+	}
+
+	if !n.Block {
+		b.WriteByte('\n')
+		return
+	}
+
+	b.WriteString(" {\n")
+	for _, c := range n.Children {
+		c.write(b, depth+1)
+	}
+	writeLines(b, n.closing)
+	b.WriteString(prefix)
+	b.WriteString("}\n")
+}
+
+// writeLines writes lines, each followed by a newline.
+func writeLines(b *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+}
+
+// parser holds the state of a single Parse call.
+type parser struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// parse parses the whole file.
+func (p *parser) parse() (*Config, error) {
+
+	c := &Config{}
+
+	// stack holds the open blocks; the root collects top level nodes.
+	root := &Node{Block: true}
+	stack := []*Node{root}
+
+	var comments []string
+	var pending []string // Raw lines seen since the last node, brace or block start.
+
+	for p.scanner.Scan() {
+
+		p.line++
+		line := strings.TrimSpace(p.scanner.Text())
+
+		switch {
+		case line == "", strings.HasPrefix(line, "//"):
+			pending = append(pending, p.scanner.Text())
+			continue
+
+		case strings.HasPrefix(line, "/*"):
+			text, raw, err := p.comment(line)
+			if err != nil {
+				return nil, err
+			}
+			comments = append(comments, text)
+			pending = append(pending, raw...)
+			continue
+
+		case line == "}":
+			if len(stack) == 1 {
+				return nil, &SyntaxError{Line: p.line, Msg: "unexpected }"}
+			}
+			if len(comments) > 0 {
+				return nil, &SyntaxError{Line: p.line, Msg: "comment is not followed by a node"}
+			}
+			stack[len(stack)-1].closing = pending
+			pending = nil
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		n, err := p.statement(line)
+		if err != nil {
+			return nil, err
+		}
+
+		n.Comments = comments
+		n.raw, n.rawComments = pending, comments
+		comments, pending = nil, nil
+
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, n)
+
+		if n.Block {
+			stack = append(stack, n)
+		}
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(stack) > 1 {
+		return nil, &SyntaxError{Line: p.line, Msg: "unexpected end of file, missing }"}
+	}
+
+	c.Nodes = root.Children
+	c.Trailer = pending
+
+	return c, nil
+}
+
+// comment reads a /* */ comment starting on the current line, which may
+// continue over several lines, and returns its text and raw lines.
+func (p *parser) comment(line string) (string, []string, error) {
+
+	raw := []string{p.scanner.Text()}
+	text := strings.TrimPrefix(line, "/*")
+
+	for !strings.HasSuffix(strings.TrimSpace(text), "*/") {
+		if !p.scanner.Scan() {
+			return "", nil, &SyntaxError{Line: p.line, Msg: "unterminated comment"}
+		}
+		p.line++
+		raw = append(raw, p.scanner.Text())
+		text += "\n" + p.scanner.Text()
+	}
+
+	text = strings.TrimSuffix(strings.TrimSpace(text), "*/")
+
+	return strings.TrimSpace(text), raw, nil
+}
+
+// statement parses a leaf or the opening line of a block.
+func (p *parser) statement(line string) (*Node, error) {
+
+	tokens, quoted, err := tokenize(line)
+	if err != nil {
+		return nil, &SyntaxError{Line: p.line, Msg: err.Error()}
+	}
+
+	n := &Node{}
+
+	if len(tokens) > 0 && tokens[len(tokens)-1] == "{" && !quoted[len(tokens)-1] {
+		n.Block = true
+		tokens, quoted = tokens[:len(tokens)-1], quoted[:len(quoted)-1]
+	}
+
+	switch len(tokens) {
+	case 1:
+	case 2:
+		n.Value, n.HasValue, n.Quoted = tokens[1], true, quoted[1]
+	default:
+		return nil, &SyntaxError{Line: p.line, Msg: fmt.Sprintf("unexpected statement %q", line)}
+	}
+
+	if quoted[0] || tokens[0] == "{" || tokens[0] == "}" {
+		return nil, &SyntaxError{Line: p.line, Msg: fmt.Sprintf("invalid node name in %q", line)}
+	}
+
+	n.Name = tokens[0]
+
+	return n, nil
+}
+
+// tokenize splits a line into words, double quoted strings and braces.
+func tokenize(line string) ([]string, []bool, error) {
+
+	var tokens []string
+	var quoted []bool
+
+	for i := 0; i < len(line); {
+
+		switch c := line[i]; {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == '"':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(line) {
+					return nil, nil, fmt.Errorf("unterminated quoted string")
+				}
+				if line[i] == '\\' && i+1 < len(line) {
+					b.WriteByte(line[i+1])
+					i += 2
+					continue
+				}
+				if line[i] == '"' {
+					i++
+					break
+				}
+				b.WriteByte(line[i])
+				i++
+			}
+			tokens = append(tokens, b.String())
+			quoted = append(quoted, true)
+
+		case c == '{' || c == '}':
+			tokens = append(tokens, string(c))
+			quoted = append(quoted, false)
+			i++
+
+		default:
+			start := i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' && line[i] != '{' && line[i] != '}' {
+				i++
+			}
+			tokens = append(tokens, line[start:i])
+			quoted = append(quoted, false)
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("empty statement")
+	}
+
+	return tokens, quoted, nil
+}
+
+// quote returns s in double quotes with quotes and backslashes escaped.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// needsQuote reports whether a value must be quoted to be parsed back.
+func needsQuote(s string) bool {
+	return s == "" || strings.ContainsAny(s, " \t\"'{};#\\")
+}
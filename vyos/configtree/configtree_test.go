@@ -0,0 +1,234 @@
+package configtree
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testConfig = `firewall {
+    ipv4 {
+        name WAN-IN {
+            default-action drop
+            rule 10 {
+                action accept
+                state established
+                state related
+            }
+        }
+    }
+}
+interfaces {
+    ethernet eth0 {
+        address 192.0.2.1/24
+        address 2001:db8::1/64
+        description "Uplink to ISP"
+        hw-id 00:00:5e:00:53:01
+    }
+    /* Management loopback */
+    loopback lo {
+    }
+}
+system {
+    host-name r1
+    login {
+        user vyos {
+            authentication {
+                plaintext-password ""
+            }
+        }
+    }
+    syslog {
+        global {
+            facility all {
+                level info
+            }
+        }
+    }
+}
+// Warning: Do not remove the following line.
+// vyos-config-version: "bgp@5:firewall@15:interfaces@32:system@27"
+// Release version: 1.4.0
+`
+
+// TestRoundTrip tests that a file written by VyOS is reproduced exactly.
+func TestRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	c, err := ParseString(testConfig)
+	if err != nil {
+		t.Fatalf("ParseString returned error: %v", err)
+	}
+
+	if got := c.String(); got != testConfig {
+		t.Errorf("String is\n%v\nwant\n%v", got, testConfig)
+	}
+
+	if got, want := c.Version(), "bgp@5:firewall@15:interfaces@32:system@27"; got != want {
+		t.Errorf("Version is %v, want %v", got, want)
+	}
+
+	if got, want := c.Release(), "1.4.0"; got != want {
+		t.Errorf("Release is %v, want %v", got, want)
+	}
+
+	if got, want := c.Nodes[1].Children[1].Comments, []string{"Management loopback"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Comments are %v, want %v", got, want)
+	}
+}
+
+// TestRoundTripLayout tests that multi-line comments and blank lines inside
+// blocks are reproduced exactly.
+func TestRoundTripLayout(t *testing.T) {
+
+	t.Parallel()
+
+	in := `interfaces {
+    /* Uplink to the ISP,
+       see ticket 42 */
+    ethernet eth0 {
+        address dhcp
+
+        // Management
+        description WAN
+
+    }
+}
+
+system {
+    host-name r1
+}
+`
+
+	c, err := ParseString(in)
+	if err != nil {
+		t.Fatalf("ParseString returned error: %v", err)
+	}
+
+	if got := c.String(); got != in {
+		t.Errorf("String is\n%v\nwant\n%v", got, in)
+	}
+
+	eth0 := c.Nodes[0].Children[0]
+	if got, want := eth0.Comments, []string{"Uplink to the ISP,\n       see ticket 42"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Comments are %q, want %q", got, want)
+	}
+
+	// A changed comment is written with the indentation of its node.
+	eth0.Comments = []string{"Uplink\nto the ISP"}
+	want := "    /* Uplink\n    to the ISP */\n    ethernet eth0 {\n"
+	if got := c.String(); !strings.Contains(got, want) {
+		t.Errorf("String is\n%v\nwant it to contain\n%v", got, want)
+	}
+}
+
+// TestTree tests conversion to and from the JSON tree shape.
+func TestTree(t *testing.T) {
+
+	t.Parallel()
+
+	c, err := ParseString(testConfig)
+	if err != nil {
+		t.Fatalf("ParseString returned error: %v", err)
+	}
+
+	tree := c.Tree()
+
+	eth0 := tree["interfaces"].(map[string]interface{})["ethernet"].(map[string]interface{})["eth0"]
+	want := map[string]interface{}{
+		"address":     []interface{}{"192.0.2.1/24", "2001:db8::1/64"},
+		"description": "Uplink to ISP",
+		"hw-id":       "00:00:5e:00:53:01",
+	}
+
+	if !reflect.DeepEqual(eth0, want) {
+		t.Errorf("Tree eth0 is %v, want %v", eth0, want)
+	}
+
+	// Rebuilding from the tree only loses the comments and footer.
+	c.Nodes[1].Children[1].Comments = nil
+	c.Trailer = nil
+
+	if got, want := FromTree(tree, nil).String(), c.String(); got != want {
+		t.Errorf("FromTree is\n%v\nwant\n%v", got, want)
+	}
+}
+
+// TestTreeTagNodes tests that nodes whose kind depends on their parent
+// round trip through the tree.
+func TestTreeTagNodes(t *testing.T) {
+
+	t.Parallel()
+
+	in := `firewall {
+    ipv4 {
+        forward {
+            filter {
+                default-action accept
+            }
+        }
+        input {
+            filter {
+                default-action drop
+            }
+        }
+    }
+}
+interfaces {
+    input ifb0 {
+        description "Ingress shaping"
+    }
+}
+`
+
+	c, err := ParseString(in)
+	if err != nil {
+		t.Fatalf("ParseString returned error: %v", err)
+	}
+
+	if got := FromTree(c.Tree(), nil).String(); got != in {
+		t.Errorf("FromTree is\n%v\nwant\n%v", got, in)
+	}
+}
+
+// TestParseErrors tests that malformed input is rejected with a line number.
+func TestParseErrors(t *testing.T) {
+
+	t.Parallel()
+
+	tests := map[string]int{
+		"system {\n    host-name r1\n":     2,
+		"}\n":                              1,
+		"system {\n    description \"x\n}": 2,
+		"a b c d\n":                        1,
+	}
+
+	for in, line := range tests {
+
+		_, err := ParseString(in)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("ParseString(%q) returned %v, want *SyntaxError", in, err)
+			continue
+		}
+
+		if serr.Line != line {
+			t.Errorf("ParseString(%q) error line is %v, want %v", in, serr.Line, line)
+		}
+	}
+}
+
+// TestNaturalLess tests the ordering of node names.
+func TestNaturalLess(t *testing.T) {
+
+	t.Parallel()
+
+	if !naturalLess("eth2", "eth10") || naturalLess("eth10", "eth2") {
+		t.Error("naturalLess does not order eth2 before eth10")
+	}
+
+	if !naturalLess("10", "100") || !naturalLess("address", "description") {
+		t.Error("naturalLess does not order plain strings")
+	}
+}
@@ -0,0 +1,241 @@
+package configtree
+
+import (
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+// TagFunc reports whether the node at path, whose value is a node with
+// children, is a tag node. Tag nodes are written as `name value { ... }`,
+// one block per child, instead of `name { value { ... } }`.
+//
+// The JSON tree shape does not record which nodes are tag nodes, so this
+// information has to be supplied when converting a tree to config.boot.
+type TagFunc func(path []string) bool
+
+// tagNodes lists the names of common VyOS tag nodes.
+var tagNodes = map[string]bool{
+	"access-list": true, "access-list6": true, "address-group": true,
+	"area": true, "as-path-list": true, "bonding": true, "bridge": true,
+	"ca": true, "certificate": true, "community-list": true, "domain-group": true,
+	"dummy": true, "ethernet": true, "extcommunity-list": true,
+	"facility": true, "from": true, "geneve": true, "host": true, "host-name": true, "instance": true,
+	"interface": true, "interface-group": true, "ipv6-address-group": true,
+	"ipv6-network-group": true, "l2tpv3": true, "large-community-list": true,
+	"loopback": true, "mac-group": true, "macsec": true, "name": true,
+	"neighbor": true, "network": true, "network-group": true, "next-hop": true,
+	"openvpn": true, "peer": true, "peer-group": true, "port-group": true,
+	"pppoe": true, "prefix-list": true, "prefix-list6": true,
+	"pseudo-ethernet": true, "public-keys": true, "range": true, "route": true,
+	"route-map": true, "route6": true, "rule": true, "server": true,
+	"shared-network-name": true, "sstpc": true, "static-mapping": true,
+	"subnet": true, "table": true, "tunnel": true, "user": true, "vif": true,
+	"vif-c": true, "vif-s": true, "virtual-ethernet": true, "vti": true,
+	"vxlan": true, "wireguard": true, "wireless": true, "wwan": true,
+	"zone": true,
+}
+
+// DefaultTagNodes is a TagFunc that recognises common VyOS tag nodes by name,
+// such as interface types, rule, route, neighbor and user. It is used by
+// FromTree when no TagFunc is given.
+func DefaultTagNodes(path []string) bool {
+
+	if len(path) == 0 {
+		return false
+	}
+
+	name := path[len(path)-1]
+
+	// `high-availability vrrp group` is a tag node but `firewall group` is not.
+	if name == "group" {
+		return len(path) > 1 && path[len(path)-2] == "vrrp"
+	}
+
+	// `interfaces input` is a tag node but `firewall ipv4 input` is not.
+	if name == "input" {
+		return len(path) > 1 && path[len(path)-2] == "interfaces"
+	}
+
+	return tagNodes[name]
+}
+
+// Tree returns the configuration as a tree in the shape returned by the
+// /retrieve endpoint: nodes are maps, leaf values are strings, repeated
+// leaves are lists of strings and valueless leaves are empty maps.
+// Comments and the footer are not part of the tree.
+func (c *Config) Tree() map[string]interface{} {
+	return nodesToTree(c.Nodes, make(map[string]interface{}))
+}
+
+// FromTree builds a configuration from a tree in the /retrieve shape.
+// isTag decides which nodes are tag nodes; if nil, DefaultTagNodes is used.
+// Nodes are ordered by name, with numbers in names compared numerically.
+func FromTree(tree map[string]interface{}, isTag TagFunc) *Config {
+
+	if isTag == nil {
+		isTag = DefaultTagNodes
+	}
+
+	return &Config{Nodes: nodesFromTree(nil, tree, isTag)}
+}
+
+// nodesToTree merges nodes into the tree m and returns it.
+func nodesToTree(nodes []*Node, m map[string]interface{}) map[string]interface{} {
+
+	for _, n := range nodes {
+		switch {
+		case n.Block && n.HasValue:
+			tag := childMap(m, n.Name)
+			tag[n.Value] = nodesToTree(n.Children, childMap(tag, n.Value))
+
+		case n.Block:
+			m[n.Name] = nodesToTree(n.Children, childMap(m, n.Name))
+
+		case n.HasValue:
+			switch existing := m[n.Name].(type) {
+			case string:
+				m[n.Name] = []interface{}{existing, n.Value}
+			case []interface{}:
+				m[n.Name] = append(existing, n.Value)
+			default:
+				m[n.Name] = n.Value
+			}
+
+		default:
+			m[n.Name] = map[string]interface{}{}
+		}
+	}
+
+	return m
+}
+
+// childMap returns the child node k of m, creating it if needed.
+func childMap(m map[string]interface{}, k string) map[string]interface{} {
+
+	if child, ok := m[k].(map[string]interface{}); ok {
+		return child
+	}
+
+	child := make(map[string]interface{})
+	m[k] = child
+
+	return child
+}
+
+// nodesFromTree converts the children of a tree node at path into nodes.
+func nodesFromTree(path []string, m map[string]interface{}, isTag TagFunc) []*Node {
+
+	var nodes []*Node
+
+	for _, k := range sortedKeys(m) {
+
+		childPath := append(append([]string(nil), path...), k)
+
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			switch {
+			case len(v) == 0:
+				nodes = append(nodes, &Node{Name: k})
+
+			case isTag(childPath):
+				for _, t := range sortedKeys(v) {
+					children, _ := v[t].(map[string]interface{})
+					nodes = append(nodes, &Node{
+						Name:     k,
+						Value:    t,
+						HasValue: true,
+						Quoted:   needsQuote(t),
+						Block:    true,
+						Children: nodesFromTree(append(childPath, t), children, isTag),
+					})
+				}
+
+			default:
+				nodes = append(nodes, &Node{
+					Name:     k,
+					Block:    true,
+					Children: nodesFromTree(childPath, v, isTag),
+				})
+			}
+
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					nodes = append(nodes, leaf(k, s))
+				}
+			}
+
+		case []string:
+			for _, s := range v {
+				nodes = append(nodes, leaf(k, s))
+			}
+
+		case string:
+			nodes = append(nodes, leaf(k, v))
+		}
+	}
+
+	return nodes
+}
+
+// leaf returns a leaf node with a value, quoted if needed.
+func leaf(name, value string) *Node {
+	return &Node{Name: name, Value: value, HasValue: true, Quoted: needsQuote(value)}
+}
+
+// sortedKeys returns the keys of m in natural order, so eth2 sorts before
+// eth10 and rule 5 before rule 10.
+func sortedKeys(m map[string]interface{}) []string {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return naturalLess(keys[i], keys[j])
+	})
+
+	return keys
+}
+
+// naturalLess compares strings, treating runs of digits as numbers.
+func naturalLess(a, b string) bool {
+
+	for a != "" && b != "" {
+
+		da, db := digitPrefix(a), digitPrefix(b)
+
+		if da != "" && db != "" {
+			na, _ := strconv.ParseUint(da, 10, 64)
+			nb, _ := strconv.ParseUint(db, 10, 64)
+			if na != nb {
+				return na < nb
+			}
+			if len(da) != len(db) {
+				return len(da) < len(db)
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+
+	return len(a) < len(b)
+}
+
+// digitPrefix returns the leading run of ASCII digits in s.
+func digitPrefix(s string) string {
+
+	i := 0
+	for i < len(s) && s[i] < unicode.MaxASCII && unicode.IsDigit(rune(s[i])) {
+		i++
+	}
+
+	return s[:i]
+}
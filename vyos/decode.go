@@ -0,0 +1,476 @@
+package vyos
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DecodeError describes a configuration value that could not be decoded
+// into the requested Go type.
+type DecodeError struct {
+	Path  Path         // Path of the value, relative to the decoded tree.
+	Value interface{}  // The value found in the configuration tree.
+	Type  reflect.Type // The Go type it could not be decoded into.
+	Err   error        // Underlying error, if any.
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+
+	msg := fmt.Sprintf("cannot decode %T into %v at %q", e.Value, e.Type, e.Path.String())
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Unmarshal decodes a configuration tree, in the shape returned by the
+// /retrieve endpoint, into the value pointed to by v.
+//
+// Struct fields are matched to configuration nodes using the `vyos` struct
+// tag, e.g. `vyos:"hw-id"`. Fields without a tag use their name converted to
+// kebab case (HostName becomes host-name) and fields tagged `vyos:"-"` are
+// skipped. Anonymous struct fields are flattened into the parent. A field of
+// type map[string]interface{} tagged `vyos:",remain"` collects all nodes that
+// are not mapped to another field, so they survive a round trip through
+// Marshal.
+//
+// VyOS quirks are handled as follows:
+//   - a multi node holding a single value decodes into a one element slice;
+//   - a valueless leaf node, such as `disable`, decodes into a bool as true;
+//   - tag nodes decode into maps keyed by the tag value, e.g. map[string]Ethernet
+//     or map[int]Rule;
+//   - leaf values decode into strings, numbers, bools or any type implementing
+//     encoding.TextUnmarshaler.
+func Unmarshal(data interface{}, v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInterfaceNil
+	}
+
+	return decodeValue(nil, data, rv.Elem())
+}
+
+// Decode decodes the configuration tree in the response into v.
+// See Unmarshal for the decoding rules.
+func (r *ConfigResponse) Decode(v interface{}) error {
+
+	if r == nil || r.RawResponse == nil {
+		return Unmarshal(nil, v)
+	}
+
+	return Unmarshal(r.Data, v)
+}
+
+// GetInto retrieves the configuration at path and decodes it into v.
+// See Unmarshal for the decoding rules.
+func GetInto[T any](ctx context.Context, s *ConfigService, path string, v *T) (*Response, error) {
+
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetPathInto(ctx, s, p, v)
+}
+
+// GetPathInto is like GetInto but takes a structured Path.
+func GetPathInto[T any](ctx context.Context, s *ConfigService, path Path, v *T) (*Response, error) {
+
+	if v == nil {
+		return nil, ErrInterfaceNil
+	}
+
+	out, resp, err := s.GetPath(ctx, path, nil)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, out.Decode(v)
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// decodeValue decodes data into rv. path is only used for error reporting.
+func decodeValue(path Path, data interface{}, rv reflect.Value) error {
+
+	// A missing node leaves the value untouched.
+	if data == nil {
+		return nil
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return decodeValue(path, data, rv.Elem())
+	}
+
+	if rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		s, ok := leafString(data)
+		if !ok {
+			return &DecodeError{Path: path, Value: data, Type: rv.Type()}
+		}
+		if err := rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return &DecodeError{Path: path, Value: data, Type: rv.Type(), Err: err}
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return &DecodeError{Path: path, Value: data, Type: rv.Type()}
+		}
+		rv.Set(reflect.ValueOf(data))
+		return nil
+
+	case reflect.Struct:
+		return decodeStruct(path, data, rv)
+
+	case reflect.Map:
+		return decodeMap(path, data, rv)
+
+	case reflect.Slice:
+		return decodeSlice(path, data, rv)
+
+	case reflect.Bool:
+		return decodeBool(path, data, rv)
+	}
+
+	// Everything else is a scalar leaf value.
+	s, ok := leafString(data)
+	if !ok {
+		return &DecodeError{Path: path, Value: data, Type: rv.Type()}
+	}
+
+	if err := setScalar(rv, s); err != nil {
+		return &DecodeError{Path: path, Value: data, Type: rv.Type(), Err: err}
+	}
+
+	return nil
+}
+
+// decodeStruct decodes a configuration node into the fields of a struct.
+func decodeStruct(path Path, data interface{}, rv reflect.Value) error {
+
+	node, ok := data.(map[string]interface{})
+	if !ok {
+		return &DecodeError{Path: path, Value: data, Type: rv.Type()}
+	}
+
+	fields := structFields(rv.Type())
+	known := make(map[string]bool, len(fields))
+
+	var remain *field
+
+	for i, f := range fields {
+
+		if f.remain {
+			remain = &fields[i]
+			continue
+		}
+
+		known[f.name] = true
+
+		child, ok := node[f.name]
+		if !ok {
+			continue
+		}
+
+		fv, err := fieldByIndex(rv, f.index)
+		if err != nil {
+			return err
+		}
+
+		if err := decodeValue(path.Append(f.name), child, fv); err != nil {
+			return err
+		}
+	}
+
+	if remain == nil {
+		return nil
+	}
+
+	// Collect the nodes that are not mapped to a field.
+	other := make(map[string]interface{})
+	for k, child := range node {
+		if !known[k] {
+			other[k] = child
+		}
+	}
+
+	if len(other) == 0 {
+		return nil
+	}
+
+	fv, err := fieldByIndex(rv, remain.index)
+	if err != nil {
+		return err
+	}
+
+	if fv.Type() != reflect.TypeOf(other) {
+		return &DecodeError{Path: path, Value: other, Type: fv.Type()}
+	}
+
+	fv.Set(reflect.ValueOf(other))
+
+	return nil
+}
+
+// decodeMap decodes the children of a tag node into a map keyed by tag value.
+func decodeMap(path Path, data interface{}, rv reflect.Value) error {
+
+	node, ok := data.(map[string]interface{})
+	if !ok {
+		return &DecodeError{Path: path, Value: data, Type: rv.Type()}
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(rv.Type(), len(node)))
+	}
+
+	kt, vt := rv.Type().Key(), rv.Type().Elem()
+
+	for k, child := range node {
+
+		key := reflect.New(kt).Elem()
+		if err := setScalar(key, k); err != nil {
+			return &DecodeError{Path: path.Append(k), Value: k, Type: kt, Err: err}
+		}
+
+		// Decode into a copy of any existing element so that maps of
+		// structs can be merged into.
+		elem := reflect.New(vt).Elem()
+		if existing := rv.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+
+		if err := decodeValue(path.Append(k), child, elem); err != nil {
+			return err
+		}
+
+		rv.SetMapIndex(key, elem)
+	}
+
+	return nil
+}
+
+// decodeSlice decodes a multi node. A single value becomes a one element slice.
+func decodeSlice(path Path, data interface{}, rv reflect.Value) error {
+
+	items, ok := data.([]interface{})
+	if !ok {
+		items = []interface{}{data}
+	}
+
+	s := reflect.MakeSlice(rv.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := decodeValue(path, item, s.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	rv.Set(s)
+
+	return nil
+}
+
+// decodeBool decodes a leaf into a bool. A valueless leaf, which VyOS
+// returns as an empty object, is true.
+func decodeBool(path Path, data interface{}, rv reflect.Value) error {
+
+	switch d := data.(type) {
+	case bool:
+		rv.SetBool(d)
+	case map[string]interface{}:
+		rv.SetBool(true)
+	case string:
+		b, err := strconv.ParseBool(d)
+		if err != nil {
+			// Any other value still means the node is present.
+			b = true
+		}
+		rv.SetBool(b)
+	default:
+		return &DecodeError{Path: path, Value: data, Type: rv.Type()}
+	}
+
+	return nil
+}
+
+// leafString returns the string form of a leaf value, unwrapping a single
+// element list.
+func leafString(data interface{}) (string, bool) {
+
+	switch d := data.(type) {
+	case string:
+		return d, true
+	case float64:
+		return strconv.FormatFloat(d, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(d), true
+	case []interface{}:
+		if len(d) == 1 {
+			return leafString(d[0])
+		}
+	}
+
+	return "", false
+}
+
+// setScalar parses s into a string, integer, float or bool value.
+func setScalar(rv reflect.Value, s string) error {
+
+	if rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(n)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+
+	default:
+		return fmt.Errorf("unsupported type %v", rv.Type())
+	}
+
+	return nil
+}
+
+// field describes a struct field mapped to a configuration node.
+type field struct {
+	name   string // Configuration node name.
+	index  []int  // Index sequence for reflect.Value.FieldByIndex.
+	remain bool   // Whether the field collects unmapped nodes.
+}
+
+// structFields returns the configuration fields of a struct type, flattening
+// anonymous struct fields.
+func structFields(t reflect.Type) []field {
+
+	var fields []field
+
+	for i := 0; i < t.NumField(); i++ {
+
+		sf := t.Field(i)
+		tag := sf.Tag.Get("vyos")
+
+		if tag == "-" {
+			continue
+		}
+
+		// Flatten untagged anonymous structs into the parent.
+		if sf.Anonymous && tag == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, f := range structFields(ft) {
+					f.index = append([]int{i}, f.index...)
+					fields = append(fields, f)
+				}
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if opts == "remain" {
+			fields = append(fields, field{index: []int{i}, remain: true})
+			continue
+		}
+
+		if name == "" {
+			name = kebabCase(sf.Name)
+		}
+
+		fields = append(fields, field{name: name, index: []int{i}})
+	}
+
+	return fields
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but allocates nil embedded
+// struct pointers along the way.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, error) {
+
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %v", rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+
+	return rv, nil
+}
+
+// kebabCase converts a Go identifier to a VyOS node name, e.g. HostName to
+// host-name and MTU to mtu.
+func kebabCase(s string) string {
+
+	r := []rune(s)
+	var b strings.Builder
+
+	for i, c := range r {
+		if unicode.IsUpper(c) && i > 0 {
+			prevLower := unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1])
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if prevLower || (unicode.IsUpper(r[i-1]) && nextLower) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+
+	return b.String()
+}
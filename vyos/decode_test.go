@@ -0,0 +1,117 @@
+package vyos
+
+import (
+	"encoding/json"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	Address     []netip.Prefix `vyos:"address"`
+	Description string         `vyos:"description"`
+}
+
+type testEthernet struct {
+	testAddress
+	HWID    string `vyos:"hw-id"`
+	MTU     int
+	Disable bool
+	VIF     map[int]testAddress `vyos:"vif"`
+	Ignored string              `vyos:"-"`
+}
+
+// TestUnmarshal tests decoding of a configuration tree into structs.
+func TestUnmarshal(t *testing.T) {
+
+	t.Parallel()
+
+	const data = `{
+		"ethernet": {
+			"eth0": {
+				"address": "192.0.2.1/24",
+				"description": "Uplink to ISP",
+				"hw-id": "00:00:5e:00:53:01",
+				"mtu": "9000",
+				"disable": {},
+				"vif": {"10": {"address": ["198.51.100.1/24", "2001:db8::1/64"]}},
+				"ignored": "x"
+			}
+		}
+	}`
+
+	var tree interface{}
+	if err := json.Unmarshal([]byte(data), &tree); err != nil {
+		t.Fatalf("Error decoding test data: %v", err)
+	}
+
+	var got struct {
+		Ethernet map[string]testEthernet `vyos:"ethernet"`
+	}
+	if err := Unmarshal(tree, &got); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	want := testEthernet{
+		testAddress: testAddress{
+			Address:     []netip.Prefix{netip.MustParsePrefix("192.0.2.1/24")},
+			Description: "Uplink to ISP",
+		},
+		HWID:    "00:00:5e:00:53:01",
+		MTU:     9000,
+		Disable: true,
+		VIF: map[int]testAddress{
+			10: {Address: []netip.Prefix{netip.MustParsePrefix("198.51.100.1/24"), netip.MustParsePrefix("2001:db8::1/64")}},
+		},
+	}
+
+	if !reflect.DeepEqual(got.Ethernet["eth0"], want) {
+		t.Errorf("Unmarshal is %+v, want %+v", got.Ethernet["eth0"], want)
+	}
+}
+
+// TestUnmarshalErrors tests that type mismatches are reported with their path.
+func TestUnmarshalErrors(t *testing.T) {
+
+	t.Parallel()
+
+	tree := map[string]interface{}{"mtu": "jumbo"}
+
+	var v struct {
+		MTU int `vyos:"mtu"`
+	}
+
+	err := Unmarshal(tree, &v)
+	derr, ok := err.(*DecodeError)
+	if !ok {
+		t.Fatalf("Unmarshal returned %v, want *DecodeError", err)
+	}
+
+	if got, want := derr.Path.String(), "mtu"; got != want {
+		t.Errorf("DecodeError Path is %v, want %v", got, want)
+	}
+
+	if err := Unmarshal(tree, v); err != ErrInterfaceNil {
+		t.Errorf("Unmarshal returned %v, want %v", err, ErrInterfaceNil)
+	}
+}
+
+// TestKebabCase tests the default mapping of field names to node names.
+func TestKebabCase(t *testing.T) {
+
+	t.Parallel()
+
+	tests := map[string]string{
+		"HostName":    "host-name",
+		"MTU":         "mtu",
+		"HWID":        "hwid",
+		"DHCPOptions": "dhcp-options",
+		"Address":     "address",
+	}
+
+	for in, want := range tests {
+		if got := kebabCase(in); got != want {
+			t.Errorf("kebabCase(%q) is %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package vyos
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ChangeKind is the kind of a configuration change.
+type ChangeKind string
+
+// ChangeKind constants
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is a single difference between two configuration trees.
+//
+// For added and removed nodes, New or Old holds the whole subtree. For a
+// value added to or removed from a multi-value leaf, it holds just that
+// value. A changed single-value leaf has both Old and New set.
+type Change struct {
+	Kind ChangeKind  `json:"kind"`
+	Path Path        `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Changes is a list of changes, ordered by path.
+type Changes []Change
+
+// Diff compares two configuration trees and returns the changes that turn
+// old into new. Change paths are relative to the root of the trees.
+func Diff(old, new ConfigTree) Changes {
+	return diffValues(nil, nodeOrEmpty(old), nodeOrEmpty(new))
+}
+
+// Requests returns the set and delete operations that apply the changes.
+func (c Changes) Requests() []Request {
+
+	var reqs []Request
+
+	for _, ch := range c {
+		switch ch.Kind {
+		case ChangeAdded:
+			reqs = append(reqs, setRequests(ch.Path, ch.New)...)
+
+		case ChangeRemoved:
+			// A removed value of a leaf is deleted by value, anything
+			// else is deleted as a whole.
+			path := ch.Path
+			if s, ok := ch.Old.(string); ok {
+				path = path.Append(s)
+			}
+			reqs = append(reqs, Request{OPMode: OPModeDelete, Path: path})
+
+		case ChangeChanged:
+			// Deleting first is correct for single and multi-value leaves.
+			reqs = append(reqs, Request{OPMode: OPModeDelete, Path: ch.Path})
+			reqs = append(reqs, setRequests(ch.Path, ch.New)...)
+		}
+	}
+
+	return reqs
+}
+
+// Commands returns the changes as `set` and `delete` CLI commands.
+func (c Changes) Commands() []string {
+
+	reqs := c.Requests()
+
+	cmds := make([]string, len(reqs))
+	for i, req := range reqs {
+		cmds[i] = string(req.OPMode) + " " + req.Path.String()
+	}
+
+	return cmds
+}
+
+// JSON returns the changes encoded as a JSON array.
+func (c Changes) JSON() ([]byte, error) {
+
+	if c == nil {
+		c = Changes{}
+	}
+
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// String returns the changes in the style of the VyOS `compare` command:
+// changes are grouped under an [edit ...] header for their parent node and
+// prefixed with + for additions and - for removals.
+func (c Changes) String() string {
+
+	var b strings.Builder
+	var section Path
+	first := true
+
+	for _, ch := range c {
+
+		if len(ch.Path) == 0 {
+			continue
+		}
+
+		parent, name := ch.Path[:len(ch.Path)-1], ch.Path[len(ch.Path)-1]
+
+		if first || !slices.Equal(parent, section) {
+			if !first {
+				b.WriteByte('\n')
+			}
+			if len(parent) == 0 {
+				b.WriteString("[edit]\n")
+			} else {
+				fmt.Fprintf(&b, "[edit %v]\n", parent.String())
+			}
+			section = parent
+			first = false
+		}
+
+		switch ch.Kind {
+		case ChangeAdded:
+			writeCompare(&b, "+", 0, name, ch.New)
+		case ChangeRemoved:
+			writeCompare(&b, "-", 0, name, ch.Old)
+		case ChangeChanged:
+			writeCompare(&b, "-", 0, name, ch.Old)
+			writeCompare(&b, "+", 0, name, ch.New)
+		}
+	}
+
+	return b.String()
+}
+
+// writeCompare writes a node in config.boot style, prefixing every line.
+func writeCompare(b *strings.Builder, prefix string, depth int, name string, node interface{}) {
+
+	indent := strings.Repeat("    ", depth)
+
+	if t, ok := node.(ConfigTree); ok {
+		node = map[string]interface{}(t)
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(n) == 0 {
+			fmt.Fprintf(b, "%v%v%v\n", prefix, indent, name)
+			return
+		}
+
+		fmt.Fprintf(b, "%v%v%v {\n", prefix, indent, name)
+		for _, k := range sortedKeys(n) {
+			writeCompare(b, prefix, depth+1, k, n[k])
+		}
+		fmt.Fprintf(b, "%v%v}\n", prefix, indent)
+
+	default:
+		for _, v := range leafValues(n) {
+			fmt.Fprintf(b, "%v%v%v %v\n", prefix, indent, name, quoteElem(v))
+		}
+	}
+}
+
+// diffValues returns the changes that turn the old node at path into the
+// new node. A nil node does not exist.
+func diffValues(path Path, old, new interface{}) Changes {
+
+	switch {
+	case old == nil && new == nil:
+		return nil
+
+	case new == nil:
+		return Changes{{Kind: ChangeRemoved, Path: path, Old: old}}
+
+	case old == nil:
+		return Changes{{Kind: ChangeAdded, Path: path, New: new}}
+	}
+
+	oldNode, oldIsNode := old.(map[string]interface{})
+	newNode, newIsNode := new.(map[string]interface{})
+
+	var changes Changes
+
+	switch {
+	case oldIsNode && newIsNode:
+		for _, k := range sortedKeys(oldNode, newNode) {
+			changes = append(changes, diffValues(path.Append(k), childOf(oldNode, k), childOf(newNode, k))...)
+		}
+
+	case !oldIsNode && !newIsNode:
+
+		oldValues, newValues := leafValues(old), leafValues(new)
+
+		// Both single values: the leaf changed value.
+		if !isList(old) && !isList(new) {
+			if len(oldValues) == 1 && len(newValues) == 1 && oldValues[0] != newValues[0] {
+				changes = append(changes, Change{Kind: ChangeChanged, Path: path, Old: oldValues[0], New: newValues[0]})
+			}
+			return changes
+		}
+
+		for _, v := range oldValues {
+			if !slices.Contains(newValues, v) {
+				changes = append(changes, Change{Kind: ChangeRemoved, Path: path, Old: v})
+			}
+		}
+
+		for _, v := range newValues {
+			if !slices.Contains(oldValues, v) {
+				changes = append(changes, Change{Kind: ChangeAdded, Path: path, New: v})
+			}
+		}
+
+	default:
+		// A leaf became a node or the other way round.
+		changes = append(changes,
+			Change{Kind: ChangeRemoved, Path: path, Old: old},
+			Change{Kind: ChangeAdded, Path: path, New: new},
+		)
+	}
+
+	return changes
+}
+
+// setRequests returns the set operations that create a node.
+func setRequests(path Path, node interface{}) []Request {
+
+	paths := SetPaths(path, node)
+	if len(paths) == 0 {
+		paths = []Path{path}
+	}
+
+	reqs := make([]Request, len(paths))
+	for i, p := range paths {
+		reqs[i] = Request{OPMode: OPModeSet, Path: p}
+	}
+
+	return reqs
+}
+
+// nodeOrEmpty returns tree as a node, treating nil as an empty tree.
+func nodeOrEmpty(tree ConfigTree) map[string]interface{} {
+
+	if tree == nil {
+		return map[string]interface{}{}
+	}
+
+	return tree
+}
+
+// childOf returns the child k of node, or nil if it does not exist.
+func childOf(node map[string]interface{}, k string) interface{} {
+
+	child, ok := node[k]
+	if !ok {
+		return nil
+	}
+
+	// A present child with a null value is an empty node.
+	if child == nil {
+		return map[string]interface{}{}
+	}
+
+	// Normalise named tree types so type switches see a plain node.
+	if t, ok := child.(ConfigTree); ok {
+		return map[string]interface{}(t)
+	}
+
+	return child
+}
+
+// isList reports whether a leaf holds a list of values.
+func isList(leaf interface{}) bool {
+
+	switch leaf.(type) {
+	case []interface{}, []string:
+		return true
+	}
+
+	return false
+}
+
+// leafValues returns the values of a leaf node as strings.
+func leafValues(leaf interface{}) []string {
+
+	var values []string
+
+	switch l := leaf.(type) {
+	case []interface{}:
+		for _, item := range l {
+			if s, ok := leafString(item); ok {
+				values = append(values, s)
+			}
+		}
+	case []string:
+		values = append(values, l...)
+	default:
+		if s, ok := leafString(l); ok {
+			values = append(values, s)
+		}
+	}
+
+	return values
+}
+
+// sortedKeys returns the union of the keys of the given nodes, sorted.
+func sortedKeys(nodes ...map[string]interface{}) []string {
+
+	seen := make(map[string]bool)
+	var keys []string
+
+	for _, n := range nodes {
+		for k := range n {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
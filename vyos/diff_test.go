@@ -0,0 +1,96 @@
+package vyos
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDiff tests the changes and renderings produced by Diff.
+func TestDiff(t *testing.T) {
+
+	t.Parallel()
+
+	old := ConfigTree{
+		"system": map[string]interface{}{
+			"host-name":   "r1",
+			"name-server": []interface{}{"192.0.2.53", "192.0.2.54"},
+		},
+		"service": map[string]interface{}{
+			"ssh": map[string]interface{}{"port": "22"},
+		},
+	}
+
+	new := ConfigTree{
+		"system": map[string]interface{}{
+			"host-name":   "r2",
+			"name-server": []interface{}{"192.0.2.53", "198.51.100.53"},
+		},
+		"protocols": map[string]interface{}{
+			"static": map[string]interface{}{
+				"route": map[string]interface{}{
+					"0.0.0.0/0": map[string]interface{}{
+						"blackhole": map[string]interface{}{},
+					},
+				},
+			},
+		},
+	}
+
+	changes := Diff(old, new)
+
+	want := Changes{
+		{Kind: ChangeAdded, Path: P("protocols"), New: new["protocols"]},
+		{Kind: ChangeRemoved, Path: P("service"), Old: old["service"]},
+		{Kind: ChangeChanged, Path: P("system", "host-name"), Old: "r1", New: "r2"},
+		{Kind: ChangeRemoved, Path: P("system", "name-server"), Old: "192.0.2.54"},
+		{Kind: ChangeAdded, Path: P("system", "name-server"), New: "198.51.100.53"},
+	}
+
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Diff is %v, want %v", changes, want)
+	}
+
+	wantCommands := []string{
+		"set protocols static route 0.0.0.0/0 blackhole",
+		"delete service",
+		"delete system host-name",
+		"set system host-name r2",
+		"delete system name-server 192.0.2.54",
+		"set system name-server 198.51.100.53",
+	}
+
+	if got := changes.Commands(); !reflect.DeepEqual(got, wantCommands) {
+		t.Errorf("Changes.Commands is %v, want %v", got, wantCommands)
+	}
+
+	wantCompare := `[edit]
++protocols {
++    static {
++        route {
++            0.0.0.0/0 {
++                blackhole
++            }
++        }
++    }
++}
+-service {
+-    ssh {
+-        port 22
+-    }
+-}
+
+[edit system]
+-host-name r1
++host-name r2
+-name-server 192.0.2.54
++name-server 198.51.100.53
+`
+
+	if got := changes.String(); got != wantCompare {
+		t.Errorf("Changes.String is\n%v\nwant\n%v", got, wantCompare)
+	}
+
+	if got := Diff(old, old); len(got) != 0 {
+		t.Errorf("Diff of identical trees is %v, want no changes", got)
+	}
+}
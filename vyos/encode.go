@@ -0,0 +1,342 @@
+package vyos
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Marshal encodes v into a configuration tree in the shape returned by the
+// /retrieve endpoint. It is the inverse of Unmarshal and uses the same
+// `vyos` struct tags.
+//
+// Zero values (empty strings, zero numbers, false, nil or empty maps and
+// slices, and structs with nothing set) are omitted. A true bool is encoded
+// as a valueless leaf node and a non-nil pointer to a struct always creates
+// its node. Use a pointer for values where zero is meaningful.
+func Marshal(v interface{}) (interface{}, error) {
+	return encodeValue(nil, reflect.ValueOf(v))
+}
+
+// SetPaths returns the `set` paths, relative to base, needed to create the
+// configuration tree. Paths are returned in a stable, sorted order.
+func SetPaths(base Path, tree interface{}) []Path {
+
+	var paths []Path
+	appendSetPaths(&paths, base, tree)
+
+	return paths
+}
+
+// Replace replaces the configuration at path with v in a single /configure
+// request. v can be a tagged struct, as understood by Marshal, or a tree in
+// the shape returned by the /retrieve endpoint. Existing configuration at
+// path is deleted first, including nodes not represented in v.
+func (s *ConfigService) Replace(ctx context.Context, path Path, v interface{}) (*ConfigResponse, *Response, error) {
+
+	if len(path) == 0 {
+		return nil, nil, ErrEmptyPath
+	}
+
+	tree, err := Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exists, resp, err := s.ExistsPath(ctx, path)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	b := s.Batch()
+	if found, _ := exists.Data.(bool); found {
+		b.DeletePath(path)
+	}
+
+	paths := SetPaths(path, tree)
+	if len(paths) == 0 {
+		// Create the node itself, e.g. an interface without any options.
+		paths = []Path{path}
+	}
+
+	for _, p := range paths {
+		b.SetPath(p)
+	}
+
+	return b.Do(ctx)
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// encodeValue encodes rv. A nil result means the value is omitted.
+func encodeValue(path Path, rv reflect.Value) (interface{}, error) {
+
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	if rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if rv.Kind() == reflect.Pointer && rv.Type().Implements(textMarshalerType) {
+			return encodeText(path, rv)
+		}
+		v, err := encodeValue(path, rv.Elem())
+		// A non-nil pointer to a struct marks the node as present even
+		// without options, e.g. `blackhole`.
+		if v == nil && err == nil && rv.Kind() == reflect.Pointer && rv.Elem().Kind() == reflect.Struct {
+			v = map[string]interface{}{}
+		}
+		return v, err
+	}
+
+	if rv.Type().Implements(textMarshalerType) {
+		if rv.IsZero() {
+			return nil, nil
+		}
+		return encodeText(path, rv)
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return encodeStruct(path, rv)
+
+	case reflect.Map:
+		return encodeMap(path, rv)
+
+	case reflect.Slice, reflect.Array:
+		var items []interface{}
+		for i := 0; i < rv.Len(); i++ {
+			item, err := encodeValue(path, rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			if item != nil {
+				items = append(items, item)
+			}
+		}
+		if len(items) == 0 {
+			return nil, nil
+		}
+		return items, nil
+
+	case reflect.Bool:
+		if !rv.Bool() {
+			return nil, nil
+		}
+		return map[string]interface{}{}, nil
+
+	case reflect.String:
+		if rv.String() == "" {
+			return nil, nil
+		}
+		return rv.String(), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if rv.IsZero() {
+			return nil, nil
+		}
+		return scalarString(rv), nil
+	}
+
+	return nil, fmt.Errorf("cannot encode %v at %q", rv.Type(), path.String())
+}
+
+// encodeStruct encodes the tagged fields of a struct into a node.
+func encodeStruct(path Path, rv reflect.Value) (interface{}, error) {
+
+	node := make(map[string]interface{})
+
+	for _, f := range structFields(rv.Type()) {
+
+		fv, ok := fieldValue(rv, f.index)
+		if !ok {
+			continue
+		}
+
+		if f.remain {
+			other, ok := fv.Interface().(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("remain field must be map[string]interface{}, got %v", fv.Type())
+			}
+			for k, child := range other {
+				if _, set := node[k]; !set {
+					node[k] = child
+				}
+			}
+			continue
+		}
+
+		child, err := encodeValue(path.Append(f.name), fv)
+		if err != nil {
+			return nil, err
+		}
+
+		if child != nil {
+			node[f.name] = child
+		}
+	}
+
+	// Structs without any options set are omitted like other zero values.
+	if len(node) == 0 {
+		return nil, nil
+	}
+
+	return node, nil
+}
+
+// encodeMap encodes a map into a tag node keyed by the map keys.
+func encodeMap(path Path, rv reflect.Value) (interface{}, error) {
+
+	if rv.Len() == 0 {
+		return nil, nil
+	}
+
+	node := make(map[string]interface{}, rv.Len())
+
+	iter := rv.MapRange()
+	for iter.Next() {
+
+		key := scalarString(iter.Key())
+
+		child, err := encodeValue(path.Append(key), iter.Value())
+		if err != nil {
+			return nil, err
+		}
+
+		// A tag node without options still exists, e.g. `ethernet eth1`.
+		if child == nil {
+			child = map[string]interface{}{}
+		}
+
+		node[key] = child
+	}
+
+	return node, nil
+}
+
+// encodeText encodes a value implementing encoding.TextMarshaler.
+func encodeText(path Path, rv reflect.Value) (interface{}, error) {
+
+	text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode %v at %q: %w", rv.Type(), path.String(), err)
+	}
+
+	if len(text) == 0 {
+		return nil, nil
+	}
+
+	return string(text), nil
+}
+
+// scalarString formats a string, integer or float value.
+func scalarString(rv reflect.Value) string {
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits())
+	}
+
+	return fmt.Sprint(rv.Interface())
+}
+
+// fieldValue returns the field at index, reporting false if an embedded
+// struct pointer along the way is nil.
+func fieldValue(rv reflect.Value, index []int) (reflect.Value, bool) {
+
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+
+	return rv, true
+}
+
+// appendSetPaths appends the set paths for node under base.
+func appendSetPaths(paths *[]Path, base Path, node interface{}) {
+
+	if t, ok := node.(ConfigTree); ok {
+		node = map[string]interface{}(t)
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+
+			child := n[k]
+
+			// An empty node is a valueless leaf or an empty tag node.
+			if m, ok := child.(map[string]interface{}); ok && len(m) == 0 {
+				*paths = append(*paths, base.Append(k))
+				continue
+			}
+
+			appendSetPaths(paths, base.Append(k), child)
+		}
+
+	case []interface{}:
+		for _, item := range n {
+			appendSetPaths(paths, base, item)
+		}
+
+	case []string:
+		for _, item := range n {
+			*paths = append(*paths, base.Append(item))
+		}
+
+	case nil:
+
+	default:
+		s, _ := leafString(n)
+		*paths = append(*paths, base.Append(s))
+	}
+}
+
+// getNode returns the configuration node at path, or an empty node if the
+// path does not exist.
+func (s *ConfigService) getNode(ctx context.Context, path Path) (map[string]interface{}, *Response, error) {
+
+	exists, resp, err := s.ExistsPath(ctx, path)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if found, _ := exists.Data.(bool); !found {
+		return map[string]interface{}{}, resp, nil
+	}
+
+	out, resp, err := s.GetPath(ctx, path, nil)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	node, _ := out.Data.(map[string]interface{})
+	if node == nil {
+		node = map[string]interface{}{}
+	}
+
+	return node, resp, nil
+}
@@ -0,0 +1,115 @@
+package vyos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+)
+
+// Encoder encodes the form fields of an API request into a request body.
+// fields always holds the JSON encoded request in `data`, and usually the API
+// key in `key`.
+type Encoder interface {
+	Encode(fields url.Values) (body []byte, contentType string, err error)
+}
+
+// EncoderFunc is an adapter to allow the use of ordinary functions as an
+// Encoder.
+type EncoderFunc func(fields url.Values) ([]byte, string, error)
+
+// Encode calls f(fields).
+func (f EncoderFunc) Encode(fields url.Values) ([]byte, string, error) {
+	return f(fields)
+}
+
+// Encoders for the request body formats accepted by the VyOS API.
+var (
+	// MultipartEncoder sends the fields as multipart/form-data. It is the
+	// default encoder.
+	MultipartEncoder Encoder = EncoderFunc(encodeMultipart)
+
+	// FormEncoder sends the fields as application/x-www-form-urlencoded.
+	FormEncoder Encoder = EncoderFunc(encodeForm)
+
+	// JSONEncoder sends a single application/json object. The other fields
+	// are merged into the request in `data`; a batch of requests is sent in
+	// `commands`.
+	JSONEncoder Encoder = EncoderFunc(encodeJSON)
+)
+
+// WithEncoder sets the request body encoder for the VyOS API client.
+func (c *Client) WithEncoder(encoder Encoder) *Client {
+	newClient := c.copy()
+	defer newClient.init()
+
+	// Set the encoder for the API client.
+	newClient.encoder = encoder
+
+	return newClient
+}
+
+// encodeMultipart encodes the fields as multipart/form-data.
+func encodeMultipart(fields url.Values) ([]byte, string, error) {
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for _, name := range sortedFields(fields) {
+		for _, value := range fields[name] {
+			if err := writer.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// encodeForm encodes the fields as application/x-www-form-urlencoded.
+func encodeForm(fields url.Values) ([]byte, string, error) {
+	return []byte(fields.Encode()), "application/x-www-form-urlencoded", nil
+}
+
+// encodeJSON encodes the fields as a single JSON object.
+func encodeJSON(fields url.Values) ([]byte, string, error) {
+
+	data := []byte(fields.Get("data"))
+
+	obj := make(map[string]json.RawMessage)
+	switch {
+	case len(data) == 0:
+
+	case data[0] == '[':
+		// A batch of requests.
+		obj["commands"] = data
+
+	default:
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, "", fmt.Errorf("cannot encode request as JSON: %w", err)
+		}
+	}
+
+	for name, values := range fields {
+		if name == "data" || len(values) == 0 {
+			continue
+		}
+		value, err := json.Marshal(values[0])
+		if err != nil {
+			return nil, "", err
+		}
+		obj[name] = value
+	}
+
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return body, "application/json", nil
+}
@@ -0,0 +1,113 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestEncoder tests that requests are sent in the selected encoding.
+func TestEncoder(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		encoder     Encoder
+		contentType string
+		want        func(t *testing.T, r *http.Request, body []byte)
+	}{
+		{
+			name:        "multipart",
+			encoder:     MultipartEncoder,
+			contentType: "multipart/form-data",
+			want: func(t *testing.T, r *http.Request, body []byte) {
+				if got, want := r.FormValue("key"), "test"; got != want {
+					t.Errorf("key is %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:        "urlencoded",
+			encoder:     FormEncoder,
+			contentType: "application/x-www-form-urlencoded",
+			want: func(t *testing.T, r *http.Request, body []byte) {
+				if got, want := r.FormValue("data"), `[{"op":"set","path":["interfaces","dummy","dum0"]}]`; got != want {
+					t.Errorf("data is %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:        "json",
+			encoder:     JSONEncoder,
+			contentType: "application/json",
+			want: func(t *testing.T, r *http.Request, body []byte) {
+				var got map[string]interface{}
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Fatalf("body is not JSON: %v", err)
+				}
+				want := map[string]interface{}{
+					"key": "test",
+					"commands": []interface{}{
+						map[string]interface{}{"op": "set", "path": []interface{}{"interfaces", "dummy", "dum0"}},
+					},
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("body is %v, want %v", got, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+				if got := r.Header.Get("Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+					t.Errorf("Content-Type is %v, want %v", got, tt.contentType)
+				}
+
+				body, _ := io.ReadAll(r.Body)
+				if got, want := r.ContentLength, int64(len(body)); got != want {
+					t.Errorf("ContentLength is %v, want %v", got, want)
+				}
+
+				r.Body = io.NopCloser(strings.NewReader(string(body)))
+				tt.want(t, r, body)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"success": true, "error": null, "data": null}`))
+			}))
+			defer srv.Close()
+
+			c := NewClient(nil).WithURL(srv.URL).WithToken("test").WithEncoder(tt.encoder)
+			if _, _, err := c.Conf.Set(context.TODO(), "interfaces dummy dum0"); err != nil {
+				t.Fatalf("Conf.Set returned error: %v", err)
+			}
+		})
+	}
+}
+
+// TestEncodeJSONObject tests that a single request is merged with the key.
+func TestEncodeJSONObject(t *testing.T) {
+
+	t.Parallel()
+
+	body, _, err := JSONEncoder.Encode(map[string][]string{
+		"data": {`{"op":"showConfig","path":["system"]}`},
+		"key":  {"test"},
+	})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+
+	if got, want := string(body), `{"key":"test","op":"showConfig","path":["system"]}`; got != want {
+		t.Errorf("Encode is %v, want %v", got, want)
+	}
+}
@@ -1,6 +1,11 @@
 package vyos
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 var (
 
@@ -9,4 +14,62 @@ var (
 	ErrInterfaceNil = errors.New("can not unmarshal into nil interface")
 	ErrEmptyPath = errors.New("path cannot be empty")
 
+	// Sentinel errors used to classify an APIError with errors.Is.
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrInvalidPath  = errors.New("invalid configuration path")
+	ErrCommitFailed = errors.New("commit failed")
+	ErrConfigLocked = errors.New("configuration is locked")
+
 )
+
+// APIError is returned by Client.Do when the VyOS API reports a failure,
+// either through an HTTP error status or a `success: false` response.
+type APIError struct {
+	StatusCode int    // HTTP status code of the response.
+	Message    string // Error text reported by VyOS.
+	Path       string // Request path, e.g. /configure.
+	Op         OPMode // Operation that was requested, e.g. set.
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+
+	if e.Op != "" {
+		return fmt.Sprintf("%v %v: %d: %v", e.Path, e.Op, e.StatusCode, msg)
+	}
+
+	return fmt.Sprintf("%v: %d: %v", e.Path, e.StatusCode, msg)
+}
+
+// Is reports whether the error matches one of the sentinel errors
+// ErrUnauthorized, ErrInvalidPath, ErrCommitFailed or ErrConfigLocked.
+func (e *APIError) Is(target error) bool {
+
+	msg := strings.ToLower(e.Message)
+
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized ||
+			e.StatusCode == http.StatusForbidden ||
+			strings.Contains(msg, "api key")
+	case ErrInvalidPath:
+		return strings.Contains(msg, "is not valid") ||
+			strings.Contains(msg, "path is empty") ||
+			strings.Contains(msg, "does not exist") ||
+			strings.Contains(msg, "doesn't exist") ||
+			strings.Contains(msg, "invalid path")
+	case ErrCommitFailed:
+		return strings.Contains(msg, "commit failed")
+	case ErrConfigLocked:
+		return e.StatusCode == http.StatusConflict ||
+			strings.Contains(msg, "locked") ||
+			strings.Contains(msg, "session is in progress")
+	}
+
+	return false
+}
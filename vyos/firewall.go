@@ -0,0 +1,343 @@
+package vyos
+
+import (
+	"context"
+	"strconv"
+)
+
+// FirewallService manages the `firewall` configuration subtree using typed
+// models. It is built on top of ConfigService.
+type FirewallService service
+
+// FirewallFamily is the address family of a firewall rule set.
+type FirewallFamily string
+
+// FirewallFamily constants
+const (
+	FirewallIPv4 FirewallFamily = "ipv4"
+	FirewallIPv6 FirewallFamily = "ipv6"
+)
+
+// FirewallGroupType is the node name of a firewall group type.
+type FirewallGroupType string
+
+// FirewallGroupType constants
+const (
+	FirewallAddressGroup     FirewallGroupType = "address-group"
+	FirewallNetworkGroup     FirewallGroupType = "network-group"
+	FirewallPortGroup        FirewallGroupType = "port-group"
+	FirewallInterfaceGroup   FirewallGroupType = "interface-group"
+	FirewallIPv6AddressGroup FirewallGroupType = "ipv6-address-group"
+	FirewallIPv6NetworkGroup FirewallGroupType = "ipv6-network-group"
+)
+
+// maxFirewallRule is the highest rule number accepted by VyOS.
+const maxFirewallRule = 999999
+
+// FirewallRuleSetRef identifies a rule set: either a named chain
+// (`firewall ipv4 name WAN-IN`) or a base chain filter
+// (`firewall ipv4 forward filter`).
+type FirewallRuleSetRef struct {
+	Family FirewallFamily
+	Name   string // Name of a named chain.
+	Hook   string // Base chain, e.g. forward, input or output. Ignored if Name is set.
+}
+
+// FirewallNamed returns a reference to a named rule set.
+func FirewallNamed(family FirewallFamily, name string) FirewallRuleSetRef {
+	return FirewallRuleSetRef{Family: family, Name: name}
+}
+
+// FirewallFilter returns a reference to the filter of a base chain.
+func FirewallFilter(family FirewallFamily, hook string) FirewallRuleSetRef {
+	return FirewallRuleSetRef{Family: family, Hook: hook}
+}
+
+// Path returns the configuration path of the rule set.
+func (r FirewallRuleSetRef) Path() Path {
+
+	if r.Name != "" {
+		return P("firewall", string(r.Family), "name", r.Name)
+	}
+
+	return P("firewall", string(r.Family), r.Hook, "filter")
+}
+
+// FirewallGroupMatch matches traffic against firewall groups.
+type FirewallGroupMatch struct {
+	AddressGroup string                 `vyos:"address-group"`
+	NetworkGroup string                 `vyos:"network-group"`
+	PortGroup    string                 `vyos:"port-group"`
+	DomainGroup  string                 `vyos:"domain-group"`
+	MACGroup     string                 `vyos:"mac-group"`
+	Other        map[string]interface{} `vyos:",remain"`
+}
+
+// FirewallMatch is the source or destination criteria of a rule.
+type FirewallMatch struct {
+	Address    string                 `vyos:"address"`
+	Port       string                 `vyos:"port"`
+	FQDN       string                 `vyos:"fqdn"`
+	MACAddress string                 `vyos:"mac-address"`
+	Group      FirewallGroupMatch     `vyos:"group"`
+	Other      map[string]interface{} `vyos:",remain"`
+}
+
+// FirewallInterfaceMatch matches the inbound or outbound interface of a rule.
+type FirewallInterfaceMatch struct {
+	Name  string `vyos:"name"`
+	Group string `vyos:"group"`
+}
+
+// FirewallRule is a rule in a rule set, keyed by rule number.
+type FirewallRule struct {
+	Action            string                 `vyos:"action"`
+	JumpTarget        string                 `vyos:"jump-target"`
+	Description       string                 `vyos:"description"`
+	Protocol          string                 `vyos:"protocol"`
+	Source            FirewallMatch          `vyos:"source"`
+	Destination       FirewallMatch          `vyos:"destination"`
+	State             []string               `vyos:"state"`
+	InboundInterface  FirewallInterfaceMatch `vyos:"inbound-interface"`
+	OutboundInterface FirewallInterfaceMatch `vyos:"outbound-interface"`
+	Log               bool                   `vyos:"log"`
+	Disable           bool                   `vyos:"disable"`
+	Other             map[string]interface{} `vyos:",remain"`
+}
+
+// FirewallRuleSet is a named chain or base chain filter.
+type FirewallRuleSet struct {
+	DefaultAction string                 `vyos:"default-action"`
+	DefaultLog    bool                   `vyos:"default-log"`
+	Description   string                 `vyos:"description"`
+	Rule          map[int]FirewallRule   `vyos:"rule"`
+	Other         map[string]interface{} `vyos:",remain"`
+}
+
+// FirewallHook holds the filter of a base chain.
+type FirewallHook struct {
+	Filter *FirewallRuleSet       `vyos:"filter"`
+	Other  map[string]interface{} `vyos:",remain"`
+}
+
+// FirewallRuleSets is the `firewall ipv4` or `firewall ipv6` subtree.
+type FirewallRuleSets struct {
+	Name    map[string]FirewallRuleSet `vyos:"name"`
+	Forward FirewallHook               `vyos:"forward"`
+	Input   FirewallHook               `vyos:"input"`
+	Output  FirewallHook               `vyos:"output"`
+	Other   map[string]interface{}     `vyos:",remain"`
+}
+
+// FirewallGroup is a firewall group. Only the member field matching the
+// group type is used, e.g. Address for an address-group.
+type FirewallGroup struct {
+	Description string                 `vyos:"description"`
+	Address     []string               `vyos:"address"`
+	Network     []string               `vyos:"network"`
+	Port        []string               `vyos:"port"`
+	Interface   []string               `vyos:"interface"`
+	Include     []string               `vyos:"include"`
+	Other       map[string]interface{} `vyos:",remain"`
+}
+
+// FirewallGroups is the `firewall group` subtree.
+type FirewallGroups struct {
+	AddressGroup     map[string]FirewallGroup `vyos:"address-group"`
+	NetworkGroup     map[string]FirewallGroup `vyos:"network-group"`
+	PortGroup        map[string]FirewallGroup `vyos:"port-group"`
+	InterfaceGroup   map[string]FirewallGroup `vyos:"interface-group"`
+	IPv6AddressGroup map[string]FirewallGroup `vyos:"ipv6-address-group"`
+	IPv6NetworkGroup map[string]FirewallGroup `vyos:"ipv6-network-group"`
+	Other            map[string]interface{}   `vyos:",remain"`
+}
+
+// FirewallZoneFrom selects the rule sets applied to traffic from another zone.
+type FirewallZoneFrom struct {
+	Firewall struct {
+		Name     string `vyos:"name"`
+		IPv6Name string `vyos:"ipv6-name"`
+	} `vyos:"firewall"`
+}
+
+// FirewallZone is a zone based firewall policy, keyed by zone name.
+type FirewallZone struct {
+	Description   string                      `vyos:"description"`
+	DefaultAction string                      `vyos:"default-action"`
+	Interface     []string                    `vyos:"interface"`
+	LocalZone     bool                        `vyos:"local-zone"`
+	From          map[string]FirewallZoneFrom `vyos:"from"`
+	Other         map[string]interface{}      `vyos:",remain"`
+}
+
+// Firewall is the `firewall` subtree.
+type Firewall struct {
+	Group FirewallGroups          `vyos:"group"`
+	IPv4  FirewallRuleSets        `vyos:"ipv4"`
+	IPv6  FirewallRuleSets        `vyos:"ipv6"`
+	Zone  map[string]FirewallZone `vyos:"zone"`
+	Other map[string]interface{}  `vyos:",remain"`
+}
+
+// Get returns the firewall configuration.
+func (s *FirewallService) Get(ctx context.Context) (*Firewall, *Response, error) {
+
+	node, resp, err := s.client.Conf.getNode(ctx, P("firewall"))
+	if err != nil {
+		return nil, resp, err
+	}
+
+	v := new(Firewall)
+	if err := Unmarshal(node, v); err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, nil
+}
+
+// GetRuleSet returns a rule set. A missing rule set is returned empty, as
+// Get does for a missing firewall.
+func (s *FirewallService) GetRuleSet(ctx context.Context, ref FirewallRuleSetRef) (*FirewallRuleSet, *Response, error) {
+
+	node, resp, err := s.client.Conf.getNode(ctx, ref.Path())
+	if err != nil {
+		return nil, resp, err
+	}
+
+	v := new(FirewallRuleSet)
+	if err := Unmarshal(node, v); err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, nil
+}
+
+// SetRule creates or replaces the rule at number.
+func (s *FirewallService) SetRule(ctx context.Context, ref FirewallRuleSetRef, number int, rule FirewallRule) (*ConfigResponse, *Response, error) {
+
+	if number <= 0 || number > maxFirewallRule {
+		return nil, nil, ErrInvalidRuleNumber
+	}
+
+	return s.client.Conf.Replace(ctx, ref.Path().Append("rule", strconv.Itoa(number)), rule)
+}
+
+// InsertRule inserts a rule at number. If number is in use, that rule and
+// any rules directly following it are renumbered up by one to make room.
+// All changes are sent in a single request.
+func (s *FirewallService) InsertRule(ctx context.Context, ref FirewallRuleSetRef, number int, rule FirewallRule) (*ConfigResponse, *Response, error) {
+
+	if number <= 0 || number > maxFirewallRule {
+		return nil, nil, ErrInvalidRuleNumber
+	}
+
+	tree, err := Marshal(rule)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	set, resp, err := s.client.Conf.getNode(ctx, ref.Path())
+	if err != nil {
+		return nil, resp, err
+	}
+
+	b := s.client.Conf.Batch()
+	if err := b.insertRule(ref.Path().Append("rule"), ruleNode(set), number, maxFirewallRule, tree); err != nil {
+		return nil, resp, err
+	}
+
+	return b.Do(ctx)
+}
+
+// RenumberRule moves the rule at from to the unused number to.
+func (s *FirewallService) RenumberRule(ctx context.Context, ref FirewallRuleSetRef, from, to int) (*ConfigResponse, *Response, error) {
+
+	if from <= 0 || to <= 0 || to > maxFirewallRule {
+		return nil, nil, ErrInvalidRuleNumber
+	}
+
+	set, resp, err := s.client.Conf.getNode(ctx, ref.Path())
+	if err != nil {
+		return nil, resp, err
+	}
+
+	rules := ruleNode(set)
+
+	rule, ok := rules[strconv.Itoa(from)]
+	if !ok {
+		return nil, resp, ErrRuleNotFound
+	}
+
+	if _, used := rules[strconv.Itoa(to)]; used {
+		return nil, resp, ErrRuleExists
+	}
+
+	b := s.client.Conf.Batch()
+	b.moveRule(ref.Path().Append("rule"), rule, from, to)
+
+	return b.Do(ctx)
+}
+
+// DeleteRule deletes the rule at number.
+func (s *FirewallService) DeleteRule(ctx context.Context, ref FirewallRuleSetRef, number int) (*ConfigResponse, *Response, error) {
+
+	if number <= 0 {
+		return nil, nil, ErrInvalidRuleNumber
+	}
+
+	return s.client.Conf.DeletePath(ctx, ref.Path().Append("rule", strconv.Itoa(number)))
+}
+
+// NextFreeRule returns the first unused rule number at or after start,
+// stepping by step, e.g. NextFreeRule(ctx, ref, 10, 10) for 10, 20, 30...
+func (s *FirewallService) NextFreeRule(ctx context.Context, ref FirewallRuleSetRef, start, step int) (int, *Response, error) {
+
+	set, resp, err := s.client.Conf.getNode(ctx, ref.Path())
+	if err != nil {
+		return 0, resp, err
+	}
+
+	n, err := nextFreeRule(ruleNode(set), start, step, maxFirewallRule)
+
+	return n, resp, err
+}
+
+// ApplyGroup replaces the named firewall group.
+func (s *FirewallService) ApplyGroup(ctx context.Context, typ FirewallGroupType, name string, group FirewallGroup) (*ConfigResponse, *Response, error) {
+
+	if name == "" {
+		return nil, nil, ErrEmptyPath
+	}
+
+	return s.client.Conf.Replace(ctx, P("firewall", "group", string(typ), name), group)
+}
+
+// RemoveGroup deletes the named firewall group.
+func (s *FirewallService) RemoveGroup(ctx context.Context, typ FirewallGroupType, name string) (*ConfigResponse, *Response, error) {
+
+	if name == "" {
+		return nil, nil, ErrEmptyPath
+	}
+
+	return s.client.Conf.DeletePath(ctx, P("firewall", "group", string(typ), name))
+}
+
+// ApplyZone replaces the named zone policy.
+func (s *FirewallService) ApplyZone(ctx context.Context, name string, zone FirewallZone) (*ConfigResponse, *Response, error) {
+
+	if name == "" {
+		return nil, nil, ErrEmptyPath
+	}
+
+	return s.client.Conf.Replace(ctx, P("firewall", "zone", name), zone)
+}
+
+// RemoveZone deletes the named zone policy.
+func (s *FirewallService) RemoveZone(ctx context.Context, name string) (*ConfigResponse, *Response, error) {
+
+	if name == "" {
+		return nil, nil, ErrEmptyPath
+	}
+
+	return s.client.Conf.DeletePath(ctx, P("firewall", "zone", name))
+}
@@ -0,0 +1,160 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// TestFirewallInsertRule tests that inserting a rule shifts the rules in its way.
+func TestFirewallInsertRule(t *testing.T) {
+
+	t.Parallel()
+
+	var configured []Request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/configure" {
+			if err := json.Unmarshal([]byte(r.FormValue("data")), &configured); err != nil {
+				t.Errorf("Error decoding request data: %v", err)
+			}
+			w.Write([]byte(`{"success": true, "data": null, "error": null}`))
+			return
+		}
+
+		var req Request
+		if err := json.Unmarshal([]byte(r.FormValue("data")), &req); err != nil {
+			t.Errorf("Error decoding request data: %v", err)
+		}
+
+		if req.OPMode == "exists" {
+			w.Write([]byte(`{"success": true, "data": true, "error": null}`))
+			return
+		}
+
+		w.Write([]byte(`{"success": true, "error": null, "data": {
+			"default-action": "drop",
+			"rule": {
+				"10": {"action": "accept", "state": ["established", "related"]},
+				"11": {"action": "drop", "state": "invalid"},
+				"20": {"action": "accept", "protocol": "icmp"}
+			}
+		}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	ref := FirewallNamed(FirewallIPv4, "WAN-IN")
+
+	set, _, err := c.Firewall.GetRuleSet(context.TODO(), ref)
+	if err != nil {
+		t.Fatalf("Firewall.GetRuleSet returned error: %v", err)
+	}
+
+	if got, want := set.Rule[10].State, []string{"established", "related"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Rule 10 State is %v, want %v", got, want)
+	}
+
+	rule := FirewallRule{
+		Action:      "accept",
+		Protocol:    "tcp",
+		Destination: FirewallMatch{Port: "22"},
+	}
+
+	if _, _, err := c.Firewall.InsertRule(context.TODO(), ref, 10, rule); err != nil {
+		t.Fatalf("Firewall.InsertRule returned error: %v", err)
+	}
+
+	base := P("firewall", "ipv4", "name", "WAN-IN", "rule")
+	want := []Request{
+		{OPMode: OPModeDelete, Path: base.Append("11")},
+		{OPMode: OPModeSet, Path: base.Append("12", "action", "drop")},
+		{OPMode: OPModeSet, Path: base.Append("12", "state", "invalid")},
+		{OPMode: OPModeDelete, Path: base.Append("10")},
+		{OPMode: OPModeSet, Path: base.Append("11", "action", "accept")},
+		{OPMode: OPModeSet, Path: base.Append("11", "state", "established")},
+		{OPMode: OPModeSet, Path: base.Append("11", "state", "related")},
+		{OPMode: OPModeSet, Path: base.Append("10", "action", "accept")},
+		{OPMode: OPModeSet, Path: base.Append("10", "destination", "port", "22")},
+		{OPMode: OPModeSet, Path: base.Append("10", "protocol", "tcp")},
+	}
+
+	if !reflect.DeepEqual(configured, want) {
+		t.Errorf("Firewall.InsertRule sent %v, want %v", configured, want)
+	}
+
+	if _, _, err := c.Firewall.RenumberRule(context.TODO(), ref, 10, 20); err != ErrRuleExists {
+		t.Errorf("Firewall.RenumberRule returned %v, want %v", err, ErrRuleExists)
+	}
+
+	n, _, err := c.Firewall.NextFreeRule(context.TODO(), ref, 10, 10)
+	if err != nil {
+		t.Fatalf("Firewall.NextFreeRule returned error: %v", err)
+	}
+
+	if n != 30 {
+		t.Errorf("Firewall.NextFreeRule is %v, want %v", n, 30)
+	}
+}
+
+// TestFirewallInsertRuleLimit tests that an insert shifting a rule past the
+// highest rule number is rejected before anything is sent, and that a missing
+// rule set is read as empty.
+func TestFirewallInsertRuleLimit(t *testing.T) {
+
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/configure" {
+			t.Errorf("Unexpected configure request %v", r.FormValue("data"))
+			w.Write([]byte(`{"success": true, "data": null, "error": null}`))
+			return
+		}
+
+		var req Request
+		if err := json.Unmarshal([]byte(r.FormValue("data")), &req); err != nil {
+			t.Errorf("Error decoding request data: %v", err)
+		}
+
+		if req.OPMode == "exists" {
+			found := req.Path[len(req.Path)-1] != "MISSING"
+			w.Write([]byte(`{"success": true, "data": ` + strconv.FormatBool(found) + `, "error": null}`))
+			return
+		}
+
+		w.Write([]byte(`{"success": true, "error": null, "data": {
+			"rule": {
+				"999998": {"action": "accept"},
+				"999999": {"action": "drop"}
+			}
+		}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	ctx := context.TODO()
+
+	// The requests share one server, so they run in order.
+	rule := FirewallRule{Action: "accept"}
+	if _, _, err := c.Firewall.InsertRule(ctx, FirewallNamed(FirewallIPv4, "WAN-IN"), 999998, rule); err != ErrNoFreeRule {
+		t.Errorf("Firewall.InsertRule returned %v, want %v", err, ErrNoFreeRule)
+	}
+
+	set, _, err := c.Firewall.GetRuleSet(ctx, FirewallNamed(FirewallIPv4, "MISSING"))
+	if err != nil {
+		t.Fatalf("Firewall.GetRuleSet returned error: %v", err)
+	}
+	if len(set.Rule) != 0 {
+		t.Errorf("Firewall.GetRuleSet rules are %v, want none", set.Rule)
+	}
+}
@@ -0,0 +1,335 @@
+package vyos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	ErrDuplicateDevice = errors.New("duplicate device name")
+	ErrRolloutAborted  = errors.New("rollout aborted after canary failure")
+	ErrUnknownCanary   = errors.New("canary device is not in the fleet")
+	ErrNoCanary        = errors.New("no canary devices selected")
+)
+
+// defaultFleetConcurrency is the number of devices a fleet calls at once
+// when Concurrency is not set.
+const defaultFleetConcurrency = 10
+
+// Device is a named router in a fleet.
+type Device struct {
+	Name   string
+	Tags   []string
+	Client *Client
+}
+
+// HasTag reports whether the device has tag.
+func (d *Device) HasTag(tag string) bool {
+	return slices.Contains(d.Tags, tag)
+}
+
+// Fleet runs calls across many routers concurrently.
+type Fleet struct {
+	Concurrency int           // Maximum number of devices called at once. Defaults to 10.
+	Timeout     time.Duration // Timeout for the call on each device. Zero means no timeout.
+
+	devices []*Device
+}
+
+// NewFleet returns a fleet of devices.
+func NewFleet(devices ...*Device) (*Fleet, error) {
+
+	f := &Fleet{}
+	for _, d := range devices {
+		if err := f.Add(d); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Add adds a device to the fleet.
+func (f *Fleet) Add(d *Device) error {
+
+	if _, ok := f.Device(d.Name); ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateDevice, d.Name)
+	}
+
+	f.devices = append(f.devices, d)
+
+	return nil
+}
+
+// Device returns the device with name.
+func (f *Fleet) Device(name string) (*Device, bool) {
+
+	for _, d := range f.devices {
+		if d.Name == name {
+			return d, true
+		}
+	}
+
+	return nil, false
+}
+
+// Devices returns the devices in the order they were added.
+func (f *Fleet) Devices() []*Device {
+	return slices.Clone(f.devices)
+}
+
+// Len returns the number of devices.
+func (f *Fleet) Len() int {
+	return len(f.devices)
+}
+
+// Filter returns a fleet of the devices for which keep returns true, with
+// the same settings.
+func (f *Fleet) Filter(keep func(d *Device) bool) *Fleet {
+
+	sub := &Fleet{Concurrency: f.Concurrency, Timeout: f.Timeout}
+	for _, d := range f.devices {
+		if keep(d) {
+			sub.devices = append(sub.devices, d)
+		}
+	}
+
+	return sub
+}
+
+// Tagged returns a fleet of the devices with any of the tags.
+func (f *Fleet) Tagged(tags ...string) *Fleet {
+	return f.Filter(func(d *Device) bool {
+		return slices.ContainsFunc(tags, d.HasTag)
+	})
+}
+
+// Named returns a fleet of the devices with any of the names.
+func (f *Fleet) Named(names ...string) *Fleet {
+	return f.Filter(func(d *Device) bool {
+		return slices.Contains(names, d.Name)
+	})
+}
+
+// DeviceError is the error of a call on a device.
+type DeviceError struct {
+	Device string
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *DeviceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Device, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
+
+// FleetResult is the result of a call on a device.
+type FleetResult[T any] struct {
+	Device   string
+	Value    T
+	Err      error
+	Duration time.Duration
+}
+
+// FleetResults are the results of a call across a fleet, in device order.
+type FleetResults[T any] []FleetResult[T]
+
+// Err returns the errors of all failed devices joined, each wrapped in a
+// *DeviceError, or nil if all calls succeeded.
+func (r FleetResults[T]) Err() error {
+
+	var errs []error
+	for _, res := range r {
+		if res.Err != nil {
+			errs = append(errs, &DeviceError{Device: res.Device, Err: res.Err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Failed returns the names of the devices whose call failed.
+func (r FleetResults[T]) Failed() []string {
+
+	var names []string
+	for _, res := range r {
+		if res.Err != nil {
+			names = append(names, res.Device)
+		}
+	}
+
+	return names
+}
+
+// Values returns the values of the successful calls by device name.
+func (r FleetResults[T]) Values() map[string]T {
+
+	values := make(map[string]T, len(r))
+	for _, res := range r {
+		if res.Err == nil {
+			values[res.Device] = res.Value
+		}
+	}
+
+	return values
+}
+
+// RunFleet calls fn for every device of the fleet, at most f.Concurrency at
+// once, each with its own timeout. It waits for all calls to finish.
+func RunFleet[T any](ctx context.Context, f *Fleet, fn func(ctx context.Context, d *Device) (T, error)) FleetResults[T] {
+	return runDevices(ctx, f, f.devices, fn)
+}
+
+// Run is like RunFleet for calls without a result.
+func (f *Fleet) Run(ctx context.Context, fn func(ctx context.Context, d *Device) error) FleetResults[struct{}] {
+	return RunFleet(ctx, f, func(ctx context.Context, d *Device) (struct{}, error) {
+		return struct{}{}, fn(ctx, d)
+	})
+}
+
+// RolloutOptions configures Rollout.
+type RolloutOptions struct {
+	// Canaries are the names of the devices changed first. If empty, the
+	// first Canary devices of the fleet are used.
+	Canaries []string
+
+	// Canary is the number of canary devices when Canaries is empty.
+	// Defaults to 1.
+	Canary int
+
+	// Verify, if set, is called for every canary after the change. An error
+	// fails the canary.
+	Verify func(ctx context.Context, d *Device) error
+}
+
+// Rollout calls fn on the canary devices, then on the rest of the fleet if
+// every canary succeeded. If a canary fails, the remaining devices are not
+// called and their results hold ErrRolloutAborted. If a named canary is not
+// in the fleet or no canary is selected, no device is called and every
+// result holds ErrUnknownCanary or ErrNoCanary.
+func Rollout[T any](ctx context.Context, f *Fleet, opts RolloutOptions, fn func(ctx context.Context, d *Device) (T, error)) FleetResults[T] {
+
+	var canaries, rest []*Device
+	for i, d := range f.devices {
+		isCanary := slices.Contains(opts.Canaries, d.Name)
+		if len(opts.Canaries) == 0 {
+			n := opts.Canary
+			if n <= 0 {
+				n = 1
+			}
+			isCanary = i < n
+		}
+		if isCanary {
+			canaries = append(canaries, d)
+		} else {
+			rest = append(rest, d)
+		}
+	}
+
+	// Never roll out without a canary step.
+	var errCanary error
+	for _, name := range opts.Canaries {
+		if _, ok := f.Device(name); !ok {
+			errCanary = fmt.Errorf("%w: %s", ErrUnknownCanary, name)
+			break
+		}
+	}
+	if errCanary == nil && len(canaries) == 0 {
+		errCanary = ErrNoCanary
+	}
+	if errCanary != nil {
+		results := make(FleetResults[T], len(f.devices))
+		for i, d := range f.devices {
+			results[i] = FleetResult[T]{Device: d.Name, Err: errCanary}
+		}
+		return results
+	}
+
+	// Verify the canaries as part of their call.
+	canaryFn := fn
+	if opts.Verify != nil {
+		canaryFn = func(ctx context.Context, d *Device) (T, error) {
+			v, err := fn(ctx, d)
+			if err == nil {
+				err = opts.Verify(ctx, d)
+			}
+			return v, err
+		}
+	}
+
+	results := runDevices(ctx, f, canaries, canaryFn)
+
+	if results.Err() != nil {
+		for _, d := range rest {
+			results = append(results, FleetResult[T]{Device: d.Name, Err: ErrRolloutAborted})
+		}
+	} else {
+		results = append(results, runDevices(ctx, f, rest, fn)...)
+	}
+
+	// Return the results in device order.
+	order := make(map[string]int, len(f.devices))
+	for i, d := range f.devices {
+		order[d.Name] = i
+	}
+	slices.SortStableFunc(results, func(a, b FleetResult[T]) int {
+		return order[a.Device] - order[b.Device]
+	})
+
+	return results
+}
+
+// runDevices calls fn for devices with the fleet's concurrency and timeout.
+func runDevices[T any](ctx context.Context, f *Fleet, devices []*Device, fn func(ctx context.Context, d *Device) (T, error)) FleetResults[T] {
+
+	limit := f.Concurrency
+	if limit <= 0 {
+		limit = defaultFleetConcurrency
+	}
+
+	results := make(FleetResults[T], len(devices))
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, d := range devices {
+
+		results[i].Device = d.Name
+
+		// Wait for a free slot, giving up if the context is done.
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, d *Device) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			deviceCtx := ctx
+			if f.Timeout > 0 {
+				var cancel context.CancelFunc
+				deviceCtx, cancel = context.WithTimeout(ctx, f.Timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			results[i].Value, results[i].Err = fn(deviceCtx, d)
+			results[i].Duration = time.Since(start)
+		}(i, d)
+	}
+
+	wg.Wait()
+
+	return results
+}
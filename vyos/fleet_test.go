@@ -0,0 +1,244 @@
+package vyos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFleetServer returns a server answering show requests with the host
+// name of the router, which is the API key.
+func newFleetServer(t *testing.T) *httptest.Server {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("key") == "bad" {
+			w.Write([]byte(`{"success": false, "error": "broken", "data": null}`))
+			return
+		}
+		w.Write([]byte(`{"success": true, "error": null, "data": "` + r.FormValue("key") + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// newTestFleet returns a fleet of devices whose token is their name.
+func newTestFleet(t *testing.T, url string, names ...string) *Fleet {
+
+	f, err := NewFleet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, name := range names {
+		tags := []string{"core"}
+		if i%2 == 1 {
+			tags = []string{"edge"}
+		}
+		c := NewClient(nil).WithURL(url).WithToken(name)
+		if err := f.Add(&Device{Name: name, Tags: tags, Client: c}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return f
+}
+
+// showHostName runs a show command on a device.
+func showHostName(ctx context.Context, d *Device) (string, error) {
+
+	out, _, err := d.Client.Show.Do(ctx, "host name")
+	if err != nil {
+		return "", err
+	}
+
+	return out.Data.(string), nil
+}
+
+// TestFleetRun tests running a call across a fleet.
+func TestFleetRun(t *testing.T) {
+
+	t.Parallel()
+
+	srv := newFleetServer(t)
+	f := newTestFleet(t, srv.URL, "r1", "r2", "bad", "r4")
+
+	results := RunFleet(context.TODO(), f, showHostName)
+
+	var names []string
+	for _, res := range results {
+		names = append(names, res.Device)
+	}
+	if want := []string{"r1", "r2", "bad", "r4"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("devices are %v, want %v", names, want)
+	}
+
+	want := map[string]string{"r1": "r1", "r2": "r2", "r4": "r4"}
+	if got := results.Values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("values are %v, want %v", got, want)
+	}
+
+	if got := results.Failed(); !reflect.DeepEqual(got, []string{"bad"}) {
+		t.Errorf("failed devices are %v, want %v", got, []string{"bad"})
+	}
+
+	err := results.Err()
+	var devErr *DeviceError
+	if !errors.As(err, &devErr) || devErr.Device != "bad" {
+		t.Errorf("error is %v, want a *DeviceError for bad", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Errorf("error is %v, want an *APIError", err)
+	}
+
+	// Filtered subsets.
+	if got := RunFleet(context.TODO(), f.Tagged("edge"), showHostName).Values(); !reflect.DeepEqual(got, map[string]string{"r2": "r2", "r4": "r4"}) {
+		t.Errorf("edge values are %v", got)
+	}
+	if got := f.Named("r1", "r4").Len(); got != 2 {
+		t.Errorf("named fleet has %v devices, want %v", got, 2)
+	}
+}
+
+// TestFleetConcurrency tests that a fleet limits concurrent calls and
+// applies the device timeout.
+func TestFleetConcurrency(t *testing.T) {
+
+	t.Parallel()
+
+	f := newTestFleet(t, "http://127.0.0.1", "r1", "r2", "r3", "r4", "r5", "r6")
+	f.Concurrency = 2
+	f.Timeout = time.Minute
+
+	var running, peak atomic.Int32
+	results := f.Run(context.TODO(), func(ctx context.Context, d *Device) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("no deadline")
+		}
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	if err := results.Err(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrency is %v, want at most %v", got, 2)
+	}
+}
+
+// TestFleetDuplicate tests that device names are unique.
+func TestFleetDuplicate(t *testing.T) {
+
+	t.Parallel()
+
+	_, err := NewFleet(&Device{Name: "r1"}, &Device{Name: "r1"})
+	if !errors.Is(err, ErrDuplicateDevice) {
+		t.Errorf("error is %v, want %v", err, ErrDuplicateDevice)
+	}
+}
+
+// TestRollout tests canary-then-rest rollouts.
+func TestRollout(t *testing.T) {
+
+	t.Parallel()
+
+	srv := newFleetServer(t)
+	errBroken := errors.New("broken")
+	errVerify := errors.New("verify failed")
+
+	tests := []struct {
+		name      string
+		devices   []string
+		opts      RolloutOptions
+		wantFirst string  // First device called, if any.
+		wantCalls int32   // Number of devices called.
+		wantErrs  []error // Errors of the results, in fleet order.
+	}{
+		{
+			name:      "canary succeeds",
+			devices:   []string{"r1", "r2", "r3"},
+			opts:      RolloutOptions{Canaries: []string{"r2"}},
+			wantFirst: "r2",
+			wantCalls: 3,
+			wantErrs:  []error{nil, nil, nil},
+		},
+		{
+			name:      "canary fails",
+			devices:   []string{"bad", "r2", "r3"},
+			wantFirst: "bad",
+			wantCalls: 1,
+			wantErrs:  []error{errBroken, ErrRolloutAborted, ErrRolloutAborted},
+		},
+		{
+			name:     "unknown canary",
+			devices:  []string{"r1", "r2"},
+			opts:     RolloutOptions{Canaries: []string{"typo"}},
+			wantErrs: []error{ErrUnknownCanary, ErrUnknownCanary},
+		},
+		{
+			name:    "verify fails",
+			devices: []string{"r1", "r2"},
+			opts: RolloutOptions{
+				Verify: func(ctx context.Context, d *Device) error { return errVerify },
+			},
+			wantFirst: "r1",
+			wantCalls: 1,
+			wantErrs:  []error{errVerify, ErrRolloutAborted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			f := newTestFleet(t, srv.URL, tt.devices...)
+
+			var (
+				calls atomic.Int32
+				first atomic.Value
+			)
+			results := Rollout(context.TODO(), f, tt.opts, func(ctx context.Context, d *Device) (string, error) {
+				calls.Add(1)
+				first.CompareAndSwap(nil, d.Name)
+				if d.Name == "bad" {
+					return "", errBroken
+				}
+				return showHostName(ctx, d)
+			})
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls are %v, want %v", got, tt.wantCalls)
+			}
+			if got, _ := first.Load().(string); got != tt.wantFirst {
+				t.Errorf("first device is %q, want %q", got, tt.wantFirst)
+			}
+
+			if len(results) != len(tt.devices) {
+				t.Fatalf("results are %v, want %v", len(results), len(tt.devices))
+			}
+			for i, res := range results {
+				if res.Device != tt.devices[i] {
+					t.Errorf("result %d is for %v, want %v", i, res.Device, tt.devices[i])
+				}
+				if !errors.Is(res.Err, tt.wantErrs[i]) {
+					t.Errorf("%s error is %v, want %v", res.Device, res.Err, tt.wantErrs[i])
+				}
+			}
+		})
+	}
+}
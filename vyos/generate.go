@@ -2,7 +2,6 @@ package vyos
 
 import (
 	"context"
-	"strings"
 )
 
 type GenerateService service
@@ -14,21 +13,31 @@ type GenerateResponse struct {
 // Do sends a request to the VyOS API and returns the response.
 func (s *GenerateService) Do(ctx context.Context, path string) (*GenerateResponse, *Response, error) {
 
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.DoPath(ctx, p)
+}
+
+// DoPath is like Do but takes a structured Path.
+func (s *GenerateService) DoPath(ctx context.Context, path Path) (*GenerateResponse, *Response, error) {
+
 	u := "/generate"
-	p := strings.Split(path, " ")
 
-	if p == nil {
+	if len(path) == 0 {
 		return nil, nil, ErrEmptyPath
 	}
 
 	// Create a new request.
 	request := Request{
 		OPMode: OPModeGenerate,
-		Path:   p,
+		Path:   path,
 	}
 
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, &request)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1,7 +1,10 @@
 package vyos
 
 import (
+	"bufio"
 	"context"
+	"strings"
+	"time"
 )
 
 // Response represents a response from the VyOS API.
@@ -22,62 +25,249 @@ type ImageDeleteRequest struct {
 	Name   string `json:"name,omitempty"`
 }
 
+// ImageSetDefaultRequest selects the image booted by default.
+type ImageSetDefaultRequest struct {
+	OPMode OPMode `json:"op,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Image is a system image installed on the router.
+type Image struct {
+	Name    string `json:"name"`
+	Default bool   `json:"default"` // Image is booted by default.
+	Running bool   `json:"running"` // Image is currently running.
+}
+
+// ImageProgress reports the status of a long-running image operation.
+type ImageProgress struct {
+	URL     string        // URL of the image being added.
+	Elapsed time.Duration // Time since the operation started.
+	Done    bool          // Operation has finished.
+	Err     error         // Error of the finished operation, if any.
+}
+
 // Add adds a new image from a url
 func (s *ImageService) Add(ctx context.Context, url string) (*ImageResponse, *Response, error) {
 
-	u := "/image"
-
 	// Create a new request.
 	request := ImageAddRequest{
 		OPMode: "add",
-		URL:   url,
+		URL:    url,
 	}
 
-	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
-	if err != nil {
-		return nil, nil, err
+	return s.image(ctx, &request)
+}
+
+// AddWithProgress is like Add but calls progress every interval while the
+// image is downloaded and installed, and once more when it is done. Adding
+// an image can take several minutes, during which the API does not respond.
+func (s *ImageService) AddWithProgress(ctx context.Context, url string, interval time.Duration, progress func(ImageProgress)) (*ImageResponse, *Response, error) {
+
+	if progress == nil {
+		return s.Add(ctx, url)
 	}
 
-	s.client.mu.Lock()
-	defer s.client.mu.Unlock()
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
 
-	// Create the Response struct & send the request.
-	v := new(ImageResponse)
-	resp, err := s.client.Do(ctx, req, v)
-	if err != nil {
-		return nil, resp, err
+	start := time.Now()
+
+	type result struct {
+		v    *ImageResponse
+		resp *Response
+		err  error
 	}
 
-	return v, resp, nil
+	// Add the image in the background and report while waiting.
+	done := make(chan result, 1)
+	go func() {
+		v, resp, err := s.Add(ctx, url)
+		done <- result{v, resp, err}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case r := <-done:
+			progress(ImageProgress{URL: url, Elapsed: time.Since(start), Done: true, Err: r.err})
+			return r.v, r.resp, r.err
+		case <-ticker.C:
+			progress(ImageProgress{URL: url, Elapsed: time.Since(start)})
+		}
+	}
 }
 
 // Delete deletes an existing image
 func (s *ImageService) Delete(ctx context.Context, name string) (*ImageResponse, *Response, error) {
 
-	u := "/image"
-
 	// Create a new request.
 	request := ImageDeleteRequest{
-		OPMode: "add",
+		OPMode: OPModeDelete,
 		Name:   name,
 	}
 
+	return s.image(ctx, &request)
+}
+
+// SetDefault sets the image booted by default.
+func (s *ImageService) SetDefault(ctx context.Context, name string) (*ImageResponse, *Response, error) {
+
+	// Create a new request.
+	request := ImageSetDefaultRequest{
+		OPMode: "set_default",
+		Name:   name,
+	}
+
+	return s.image(ctx, &request)
+}
+
+// List returns the installed images, parsed from `show system image`.
+func (s *ImageService) List(ctx context.Context) ([]Image, *Response, error) {
+
+	out, resp, err := s.client.Show.DoPath(ctx, P("system", "image"))
+	if err != nil {
+		return nil, resp, err
+	}
+
+	text, _ := out.Data.(string)
+
+	return parseImages(text), resp, nil
+}
+
+// image sends a request to the /image endpoint while holding the client lock.
+func (s *ImageService) image(ctx context.Context, request interface{}) (*ImageResponse, *Response, error) {
+
+	u := "/image"
+
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, request)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	s.client.mu.Lock()
-	defer s.client.mu.Unlock()
-
 	// Create the Response struct & send the request.
 	v := new(ImageResponse)
-	resp, err := s.client.Do(ctx, req, v)
+	resp, err := s.client.doLocked(ctx, req, v)
 	if err != nil {
 		return nil, resp, err
 	}
 
 	return v, resp, nil
 }
+
+// parseImages parses the output of `show system image`. Both the numbered
+// list of VyOS 1.3 and 1.4:
+//
+//	The system currently has the following image(s) installed:
+//
+//	   1: 1.4.0 (default boot) (running image)
+//	   2: 1.3.2
+//
+// and the table of VyOS 1.5 are understood:
+//
+//	Name                      Default boot    Running
+//	------------------------  --------------  ---------
+//	1.5-rolling-202405060019  Yes             Yes
+//	1.4.0
+func parseImages(text string) []Image {
+
+	var (
+		images  []Image
+		columns []int // Start offsets of the table columns.
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+
+		switch {
+		case line == "":
+
+		case strings.HasPrefix(line, "---"):
+			// The table body starts after the header separator, whose
+			// dashes mark the columns. Cells that do not apply are blank,
+			// so rows are cut at these offsets rather than split on spaces.
+			columns = columnOffsets(raw)
+
+		case columns != nil:
+			cells := cutColumns(raw, columns)
+			images = append(images, Image{
+				Name:    cells[0],
+				Default: strings.EqualFold(cells[1], "yes"),
+				Running: strings.EqualFold(cells[2], "yes"),
+			})
+
+		default:
+			// Numbered list entries, e.g. `1: 1.4.0 (default boot)`.
+			num, rest, ok := strings.Cut(line, ":")
+			if !ok || !isDigits(num) {
+				continue
+			}
+			fields := strings.Fields(rest)
+			if len(fields) == 0 {
+				continue
+			}
+			images = append(images, Image{
+				Name:    fields[0],
+				Default: strings.Contains(rest, "(default boot)"),
+				Running: strings.Contains(rest, "(running image)"),
+			})
+		}
+	}
+
+	return images
+}
+
+// columnOffsets returns the offsets at which the runs of dashes of a table
+// separator line start.
+func columnOffsets(separator string) []int {
+
+	var offsets []int
+	for i := 0; i < len(separator); i++ {
+		if separator[i] == '-' && (i == 0 || separator[i-1] != '-') {
+			offsets = append(offsets, i)
+		}
+	}
+
+	return offsets
+}
+
+// cutColumns returns the trimmed cells of a table row, cut at the column
+// offsets. At least three cells are returned; missing cells are empty.
+func cutColumns(row string, offsets []int) []string {
+
+	cells := make([]string, max(len(offsets), 3))
+	for i, start := range offsets {
+		if start >= len(row) {
+			break
+		}
+		end := len(row)
+		if i+1 < len(offsets) && offsets[i+1] < end {
+			end = offsets[i+1]
+		}
+		cells[i] = strings.TrimSpace(row[start:end])
+	}
+
+	return cells
+}
+
+// isDigits reports whether s is a non-empty string of decimal digits.
+func isDigits(s string) bool {
+
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,118 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestParseImages tests parsing of `show system image` output.
+func TestParseImages(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want []Image
+	}{
+		{
+			name: "numbered",
+			text: "The system currently has the following image(s) installed:\n\n" +
+				"   1: 1.4-rolling-202301260317 (default boot) (running image)\n" +
+				"   2: 1.3.2\n",
+			want: []Image{
+				{Name: "1.4-rolling-202301260317", Default: true, Running: true},
+				{Name: "1.3.2"},
+			},
+		},
+		{
+			name: "table",
+			// Cells that do not apply are left blank.
+			text: "Name                      Default boot    Running\n" +
+				"------------------------  --------------  ---------\n" +
+				"1.5-rolling-202405060019  Yes\n" +
+				"1.4.0                                     Yes\n" +
+				"1.4-rolling-202312200024\n",
+			want: []Image{
+				{Name: "1.5-rolling-202405060019", Default: true},
+				{Name: "1.4.0", Running: true},
+				{Name: "1.4-rolling-202312200024"},
+			},
+		},
+		{
+			name: "empty",
+			text: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseImages(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseImages is %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestImageRequests tests the operations sent by ImageService.
+func TestImageRequests(t *testing.T) {
+
+	t.Parallel()
+
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		json.Unmarshal([]byte(r.FormValue("data")), &got)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success": true, "error": null, "data": ""}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+
+	tests := []struct {
+		name string
+		call func() error
+		want map[string]interface{}
+	}{
+		{
+			name: "delete",
+			call: func() error { _, _, err := c.Image.Delete(context.TODO(), "1.3.2"); return err },
+			want: map[string]interface{}{"op": "delete", "name": "1.3.2"},
+		},
+		{
+			name: "set default",
+			call: func() error { _, _, err := c.Image.SetDefault(context.TODO(), "1.4.0"); return err },
+			want: map[string]interface{}{"op": "set_default", "name": "1.4.0"},
+		},
+		{
+			name: "add with progress",
+			call: func() error {
+				var done bool
+				_, _, err := c.Image.AddWithProgress(context.TODO(), "https://example.com/vyos.iso", time.Second, func(p ImageProgress) {
+					done = p.Done
+				})
+				if !done {
+					t.Error("AddWithProgress did not report completion")
+				}
+				return err
+			},
+			want: map[string]interface{}{"op": "add", "url": "https://example.com/vyos.iso"},
+		},
+	}
+
+	// The requests share one server, so they run in order.
+	for _, tt := range tests {
+		if err := tt.call(); err != nil {
+			t.Fatalf("%s returned error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s request is %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package vyos
+
+import (
+	"context"
+	"reflect"
+)
+
+// InterfacesService manages the `interfaces` configuration subtree using
+// typed models. It is built on top of ConfigService.
+type InterfacesService service
+
+// InterfaceType is the node name of an interface type under `interfaces`.
+type InterfaceType string
+
+// InterfaceType constants
+const (
+	InterfaceTypeEthernet  InterfaceType = "ethernet"
+	InterfaceTypeBonding   InterfaceType = "bonding"
+	InterfaceTypeBridge    InterfaceType = "bridge"
+	InterfaceTypeDummy     InterfaceType = "dummy"
+	InterfaceTypeLoopback  InterfaceType = "loopback"
+	InterfaceTypeWireGuard InterfaceType = "wireguard"
+)
+
+// Interface is implemented by the typed interface models.
+type Interface interface {
+	InterfaceType() InterfaceType // Node name of the interface type, e.g. ethernet.
+	InterfaceName() string        // Name of the interface, e.g. eth0.
+}
+
+// InterfaceCommon holds the options shared by all interface types. Nodes that
+// are not modelled are kept in Other so they are preserved by Apply.
+type InterfaceCommon struct {
+	Address     []string               `vyos:"address"`
+	Description string                 `vyos:"description"`
+	Disable     bool                   `vyos:"disable"`
+	MTU         int                    `vyos:"mtu"`
+	VRF         string                 `vyos:"vrf"`
+	Other       map[string]interface{} `vyos:",remain"`
+}
+
+// VIF is an 802.1q VLAN sub-interface, keyed by VLAN ID in its parent.
+type VIF struct {
+	InterfaceCommon
+}
+
+// Ethernet is an `interfaces ethernet` node.
+type Ethernet struct {
+	Name string `vyos:"-"`
+	InterfaceCommon
+	HWID   string      `vyos:"hw-id"`
+	MAC    string      `vyos:"mac"`
+	Speed  string      `vyos:"speed"`
+	Duplex string      `vyos:"duplex"`
+	VIF    map[int]VIF `vyos:"vif"`
+}
+
+// BondMember lists the member interfaces of a bond.
+type BondMember struct {
+	Interface []string `vyos:"interface"`
+}
+
+// Bond is an `interfaces bonding` node.
+type Bond struct {
+	Name string `vyos:"-"`
+	InterfaceCommon
+	Mode       string      `vyos:"mode"`
+	HashPolicy string      `vyos:"hash-policy"`
+	Primary    string      `vyos:"primary"`
+	Member     BondMember  `vyos:"member"`
+	VIF        map[int]VIF `vyos:"vif"`
+}
+
+// BridgeMemberInterface holds the per-member options of a bridge port.
+type BridgeMemberInterface struct {
+	AllowedVLAN []string               `vyos:"allowed-vlan"`
+	NativeVLAN  int                    `vyos:"native-vlan"`
+	Cost        int                    `vyos:"cost"`
+	Priority    int                    `vyos:"priority"`
+	Other       map[string]interface{} `vyos:",remain"`
+}
+
+// BridgeMember lists the member interfaces of a bridge.
+type BridgeMember struct {
+	Interface map[string]BridgeMemberInterface `vyos:"interface"`
+}
+
+// Bridge is an `interfaces bridge` node.
+type Bridge struct {
+	Name string `vyos:"-"`
+	InterfaceCommon
+	STP        bool         `vyos:"stp"`
+	EnableVLAN bool         `vyos:"enable-vlan"`
+	Member     BridgeMember `vyos:"member"`
+	VIF        map[int]VIF  `vyos:"vif"`
+}
+
+// Dummy is an `interfaces dummy` node.
+type Dummy struct {
+	Name string `vyos:"-"`
+	InterfaceCommon
+}
+
+// Loopback is an `interfaces loopback` node.
+type Loopback struct {
+	Name string `vyos:"-"`
+	InterfaceCommon
+}
+
+// WireGuardPeer is a peer of a WireGuard interface, keyed by peer name.
+type WireGuardPeer struct {
+	PublicKey           string                 `vyos:"public-key"`
+	PresharedKey        string                 `vyos:"preshared-key"`
+	AllowedIPs          []string               `vyos:"allowed-ips"`
+	Address             string                 `vyos:"address"`
+	Port                int                    `vyos:"port"`
+	PersistentKeepalive int                    `vyos:"persistent-keepalive"`
+	Disable             bool                   `vyos:"disable"`
+	Other               map[string]interface{} `vyos:",remain"`
+}
+
+// WireGuard is an `interfaces wireguard` node.
+type WireGuard struct {
+	Name string `vyos:"-"`
+	InterfaceCommon
+	PrivateKey string                   `vyos:"private-key"`
+	Port       int                      `vyos:"port"`
+	Peer       map[string]WireGuardPeer `vyos:"peer"`
+}
+
+func (i *Ethernet) InterfaceType() InterfaceType  { return InterfaceTypeEthernet }
+func (i *Bond) InterfaceType() InterfaceType      { return InterfaceTypeBonding }
+func (i *Bridge) InterfaceType() InterfaceType    { return InterfaceTypeBridge }
+func (i *Dummy) InterfaceType() InterfaceType     { return InterfaceTypeDummy }
+func (i *Loopback) InterfaceType() InterfaceType  { return InterfaceTypeLoopback }
+func (i *WireGuard) InterfaceType() InterfaceType { return InterfaceTypeWireGuard }
+
+func (i *Ethernet) InterfaceName() string  { return i.Name }
+func (i *Bond) InterfaceName() string      { return i.Name }
+func (i *Bridge) InterfaceName() string    { return i.Name }
+func (i *Dummy) InterfaceName() string     { return i.Name }
+func (i *Loopback) InterfaceName() string  { return i.Name }
+func (i *WireGuard) InterfaceName() string { return i.Name }
+
+// Interfaces is the `interfaces` subtree. Interface types that are not
+// modelled are kept in Other.
+type Interfaces struct {
+	Ethernet  map[string]*Ethernet   `vyos:"ethernet"`
+	Bonding   map[string]*Bond       `vyos:"bonding"`
+	Bridge    map[string]*Bridge     `vyos:"bridge"`
+	Dummy     map[string]*Dummy      `vyos:"dummy"`
+	Loopback  map[string]*Loopback   `vyos:"loopback"`
+	WireGuard map[string]*WireGuard  `vyos:"wireguard"`
+	Other     map[string]interface{} `vyos:",remain"`
+}
+
+// List returns all configured interfaces.
+func (s *InterfacesService) List(ctx context.Context) (*Interfaces, *Response, error) {
+
+	v := new(Interfaces)
+	resp, err := GetPathInto(ctx, s.client.Conf, P("interfaces"), v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	// Names are the keys of the tag nodes.
+	for name, i := range v.Ethernet {
+		i.Name = name
+	}
+	for name, i := range v.Bonding {
+		i.Name = name
+	}
+	for name, i := range v.Bridge {
+		i.Name = name
+	}
+	for name, i := range v.Dummy {
+		i.Name = name
+	}
+	for name, i := range v.Loopback {
+		i.Name = name
+	}
+	for name, i := range v.WireGuard {
+		i.Name = name
+	}
+
+	return v, resp, nil
+}
+
+// Get reads the configuration of the named interface into iface, which must
+// be a pointer to one of the interface models with its Name set:
+//
+//	eth0 := &vyos.Ethernet{Name: "eth0"}
+//	_, err := c.Interfaces.Get(ctx, eth0)
+func (s *InterfacesService) Get(ctx context.Context, iface Interface) (*Response, error) {
+
+	path, err := interfacePath(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	out, resp, err := s.client.Conf.GetPath(ctx, path, nil)
+	if err != nil {
+		return resp, err
+	}
+
+	// Decode into a fresh value so options removed on the router do not
+	// linger, then restore the name.
+	rv := reflect.ValueOf(iface).Elem()
+	v := reflect.New(rv.Type())
+
+	if err := out.Decode(v.Interface()); err != nil {
+		return resp, err
+	}
+
+	if name := v.Elem().FieldByName("Name"); name.IsValid() && name.Kind() == reflect.String {
+		name.SetString(iface.InterfaceName())
+	}
+
+	rv.Set(v.Elem())
+
+	return resp, nil
+}
+
+// Apply replaces the configuration of the interface with iface in a single
+// request. Options not set in iface are removed from the router, except
+// nodes preserved in Other.
+func (s *InterfacesService) Apply(ctx context.Context, iface Interface) (*ConfigResponse, *Response, error) {
+
+	path, err := interfacePath(iface)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.client.Conf.Replace(ctx, path, iface)
+}
+
+// Remove deletes the interface.
+func (s *InterfacesService) Remove(ctx context.Context, iface Interface) (*ConfigResponse, *Response, error) {
+
+	path, err := interfacePath(iface)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.client.Conf.DeletePath(ctx, path)
+}
+
+// interfacePath returns the configuration path of an interface.
+func interfacePath(iface Interface) (Path, error) {
+
+	if iface == nil || reflect.ValueOf(iface).IsNil() {
+		return nil, ErrInterfaceNil
+	}
+
+	if iface.InterfaceName() == "" {
+		return nil, ErrEmptyPath
+	}
+
+	return P("interfaces", string(iface.InterfaceType()), iface.InterfaceName()), nil
+}
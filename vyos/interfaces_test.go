@@ -0,0 +1,94 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestInterfacesGetApply tests reading an interface and applying it back.
+func TestInterfacesGetApply(t *testing.T) {
+
+	t.Parallel()
+
+	var configured []Request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/configure" {
+			if err := json.Unmarshal([]byte(r.FormValue("data")), &configured); err != nil {
+				t.Errorf("Error decoding request data: %v", err)
+			}
+			w.Write([]byte(`{"success": true, "data": null, "error": null}`))
+			return
+		}
+
+		var req Request
+		if err := json.Unmarshal([]byte(r.FormValue("data")), &req); err != nil {
+			t.Errorf("Error decoding request data: %v", err)
+		}
+
+		if want := P("interfaces", "ethernet", "eth0"); !reflect.DeepEqual(req.Path, want) {
+			t.Errorf("Request path is %v, want %v", req.Path, want)
+		}
+
+		switch req.OPMode {
+		case "exists":
+			w.Write([]byte(`{"success": true, "data": true, "error": null}`))
+		default:
+			w.Write([]byte(`{"success": true, "error": null, "data": {
+				"address": "192.0.2.1/24",
+				"hw-id": "00:00:5e:00:53:01",
+				"offload": {"gro": {}},
+				"vif": {"10": {"description": "Guest VLAN"}}
+			}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+
+	eth0 := &Ethernet{Name: "eth0", Speed: "stale"}
+	if _, err := c.Interfaces.Get(context.TODO(), eth0); err != nil {
+		t.Fatalf("Interfaces.Get returned error: %v", err)
+	}
+
+	if got, want := eth0.Name, "eth0"; got != want {
+		t.Errorf("Ethernet Name is %v, want %v", got, want)
+	}
+
+	if eth0.Speed != "" {
+		t.Errorf("Ethernet Speed is %v, want it cleared", eth0.Speed)
+	}
+
+	if got, want := eth0.VIF[10].Description, "Guest VLAN"; got != want {
+		t.Errorf("Ethernet VIF 10 Description is %v, want %v", got, want)
+	}
+
+	eth0.Address = append(eth0.Address, "198.51.100.1/24")
+	eth0.Disable = true
+
+	if _, _, err := c.Interfaces.Apply(context.TODO(), eth0); err != nil {
+		t.Fatalf("Interfaces.Apply returned error: %v", err)
+	}
+
+	base := P("interfaces", "ethernet", "eth0")
+	want := []Request{
+		{OPMode: OPModeDelete, Path: base},
+		{OPMode: OPModeSet, Path: base.Append("address", "192.0.2.1/24")},
+		{OPMode: OPModeSet, Path: base.Append("address", "198.51.100.1/24")},
+		{OPMode: OPModeSet, Path: base.Append("disable")},
+		{OPMode: OPModeSet, Path: base.Append("hw-id", "00:00:5e:00:53:01")},
+		{OPMode: OPModeSet, Path: base.Append("offload", "gro")},
+		{OPMode: OPModeSet, Path: base.Append("vif", "10", "description", "Guest VLAN")},
+	}
+
+	if !reflect.DeepEqual(configured, want) {
+		t.Errorf("Interfaces.Apply sent %v, want %v", configured, want)
+	}
+}
@@ -0,0 +1,452 @@
+// Package inventory loads router inventories: files describing the VyOS
+// routers of a network with their groups, tags, credentials and TLS
+// settings, from which it builds ready clients and fleets.
+//
+// An inventory is written in YAML, JSON or TOML:
+//
+//	defaults:
+//	  token_env: VYOS_TOKEN
+//	fleet:
+//	  concurrency: 5
+//	  timeout: 30s
+//	groups:
+//	  lab:
+//	    tags: [test]
+//	    tls:
+//	      insecure: true
+//	devices:
+//	  edge1:
+//	    url: https://192.0.2.1
+//	    groups: [lab]
+//	    tags: [edge]
+//	    token_file: secrets/edge1.key
+//	  edge2:
+//	    url: https://192.0.2.2
+//	    tls:
+//	      ca_file: ca.pem
+//	      server_name: edge2.example.com
+//
+// The settings of a device override those of its groups, in the order
+// listed, which override the defaults. A device is tagged with its own tags,
+// the names of its groups and their tags. Relative file paths are resolved
+// against the directory of the inventory file.
+package inventory
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ganawaj/go-vyos/vyos"
+)
+
+var (
+	ErrUnknownFormat      = errors.New("unknown file format")
+	ErrUnknownDevice      = errors.New("unknown device")
+	ErrUnknownGroup       = errors.New("unknown group")
+	ErrMissingURL         = errors.New("device has no url")
+	ErrMissingCredential  = errors.New("missing credential")
+	ErrCredentialConflict = errors.New("more than one of token, token_env and token_file")
+	ErrMissingCertFile    = errors.New("key_file without cert_file")
+)
+
+// Format is the encoding of an inventory file.
+type Format string
+
+// Inventory file formats.
+const (
+	YAML Format = "yaml"
+	JSON Format = "json"
+	TOML Format = "toml"
+)
+
+// Inventory describes the routers of a network.
+type Inventory struct {
+	Defaults Settings            `yaml:"defaults" json:"defaults" toml:"defaults"`
+	Fleet    FleetSettings       `yaml:"fleet" json:"fleet" toml:"fleet"`
+	Groups   map[string]Settings `yaml:"groups" json:"groups" toml:"groups"`
+	Devices  map[string]Device   `yaml:"devices" json:"devices" toml:"devices"`
+
+	dir string // Directory relative file paths are resolved against.
+}
+
+// Settings are the settings shared by the defaults, groups and devices.
+// Exactly one of Token, TokenEnv and TokenFile sets the API key.
+type Settings struct {
+	Tags      []string `yaml:"tags" json:"tags" toml:"tags"`
+	Token     string   `yaml:"token" json:"token" toml:"token"`
+	TokenEnv  string   `yaml:"token_env" json:"token_env" toml:"token_env"`    // Environment variable holding the key.
+	TokenFile string   `yaml:"token_file" json:"token_file" toml:"token_file"` // File holding the key.
+	TLS       TLS      `yaml:"tls" json:"tls" toml:"tls"`
+}
+
+// TLS are the TLS settings of a device.
+type TLS struct {
+	Insecure   *bool  `yaml:"insecure" json:"insecure" toml:"insecure"`          // Skip certificate verification.
+	CAFile     string `yaml:"ca_file" json:"ca_file" toml:"ca_file"`             // PEM file of CAs to trust.
+	CertFile   string `yaml:"cert_file" json:"cert_file" toml:"cert_file"`       // Client certificate.
+	KeyFile    string `yaml:"key_file" json:"key_file" toml:"key_file"`          // Client certificate key.
+	ServerName string `yaml:"server_name" json:"server_name" toml:"server_name"` // Name to verify the certificate against.
+}
+
+// Device is a router of the inventory.
+type Device struct {
+	URL      string   `yaml:"url" json:"url" toml:"url"`
+	Groups   []string `yaml:"groups" json:"groups" toml:"groups"`
+	Settings `yaml:",inline"`
+}
+
+// FleetSettings configure the fleet built by NewFleet.
+type FleetSettings struct {
+	Concurrency int      `yaml:"concurrency" json:"concurrency" toml:"concurrency"`
+	Timeout     Duration `yaml:"timeout" json:"timeout" toml:"timeout"`
+}
+
+// Duration is a time.Duration written as a string such as "30s".
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	*d = Duration(v)
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load reads an inventory file. The format is chosen by the file extension:
+// .yaml, .yml, .json or .toml.
+func Load(path string) (*Inventory, error) {
+
+	var format Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = YAML
+	case ".json":
+		format = JSON
+	case ".toml":
+		format = TOML
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := Parse(b, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	inv.dir = filepath.Dir(path)
+
+	return inv, nil
+}
+
+// Parse decodes and validates an inventory. Unknown fields are an error.
+// Relative file paths are resolved against the working directory.
+func Parse(data []byte, format Format) (*Inventory, error) {
+
+	inv := &Inventory{}
+
+	switch format {
+	case YAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(inv); err != nil {
+			return nil, err
+		}
+
+	case JSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(inv); err != nil {
+			return nil, err
+		}
+
+	case TOML:
+		md, err := toml.Decode(string(data), inv)
+		if err != nil {
+			return nil, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("unknown field %q", undecoded[0].String())
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	if err := inv.Validate(); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// Validate checks that every device has a URL and known groups, and that
+// the defaults, groups and devices each set at most one credential and no
+// key_file without a cert_file.
+func (inv *Inventory) Validate() error {
+
+	var errs []error
+	for _, err := range inv.Defaults.validate() {
+		errs = append(errs, fmt.Errorf("%w: defaults", err))
+	}
+
+	groups := make([]string, 0, len(inv.Groups))
+	for name := range inv.Groups {
+		groups = append(groups, name)
+	}
+	slices.Sort(groups)
+
+	for _, name := range groups {
+		for _, err := range inv.Groups[name].validate() {
+			errs = append(errs, fmt.Errorf("%w: group %s", err, name))
+		}
+	}
+
+	for _, name := range inv.Names() {
+		d := inv.Devices[name]
+		for _, err := range d.Settings.validate() {
+			errs = append(errs, fmt.Errorf("%w: %s", err, name))
+		}
+		if d.URL == "" {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrMissingURL, name))
+		}
+		for _, g := range d.Groups {
+			if _, ok := inv.Groups[g]; !ok {
+				errs = append(errs, fmt.Errorf("%w: %s: %s", ErrUnknownGroup, name, g))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate returns the problems of a single level of settings.
+func (s Settings) validate() []error {
+
+	set := 0
+	for _, v := range []string{s.Token, s.TokenEnv, s.TokenFile} {
+		if v != "" {
+			set++
+		}
+	}
+
+	var errs []error
+	if set > 1 {
+		errs = append(errs, ErrCredentialConflict)
+	}
+	if s.TLS.KeyFile != "" && s.TLS.CertFile == "" {
+		errs = append(errs, ErrMissingCertFile)
+	}
+
+	return errs
+}
+
+// Names returns the device names in sorted order.
+func (inv *Inventory) Names() []string {
+
+	names := make([]string, 0, len(inv.Devices))
+	for name := range inv.Devices {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Resolve returns the settings of a device merged with its groups and the
+// defaults.
+func (inv *Inventory) Resolve(name string) (Settings, error) {
+
+	d, ok := inv.Devices[name]
+	if !ok {
+		return Settings{}, fmt.Errorf("%w: %s", ErrUnknownDevice, name)
+	}
+
+	s := inv.Defaults
+	s.Tags = slices.Clone(s.Tags)
+	for _, g := range d.Groups {
+		group, ok := inv.Groups[g]
+		if !ok {
+			return Settings{}, fmt.Errorf("%w: %s: %s", ErrUnknownGroup, name, g)
+		}
+		s = merge(s, Settings{Tags: []string{g}})
+		s = merge(s, group)
+	}
+
+	return merge(s, d.Settings), nil
+}
+
+// NewClient returns a client for a device.
+func (inv *Inventory) NewClient(name string) (*vyos.Client, error) {
+
+	s, err := inv.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := inv.token(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	c := vyos.NewClient(nil).WithURL(strings.TrimSuffix(inv.Devices[name].URL, "/")).WithToken(token)
+
+	config, err := inv.tlsConfig(s.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if config != nil {
+		c = c.WithTLSConfig(config)
+	}
+
+	return c, nil
+}
+
+// NewFleet returns a fleet of all devices, in name order, with the fleet
+// settings of the inventory.
+func (inv *Inventory) NewFleet() (*vyos.Fleet, error) {
+
+	f, err := vyos.NewFleet()
+	if err != nil {
+		return nil, err
+	}
+	f.Concurrency = inv.Fleet.Concurrency
+	f.Timeout = time.Duration(inv.Fleet.Timeout)
+
+	for _, name := range inv.Names() {
+		c, err := inv.NewClient(name)
+		if err != nil {
+			return nil, err
+		}
+
+		s, _ := inv.Resolve(name)
+		if err := f.Add(&vyos.Device{Name: name, Tags: s.Tags, Client: c}); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// merge returns s overridden by the settings set in o. Tags are combined.
+func merge(s, o Settings) Settings {
+
+	for _, tag := range o.Tags {
+		if !slices.Contains(s.Tags, tag) {
+			s.Tags = append(s.Tags, tag)
+		}
+	}
+
+	// A credential replaces the inherited one, whatever its kind.
+	if o.Token != "" || o.TokenEnv != "" || o.TokenFile != "" {
+		s.Token, s.TokenEnv, s.TokenFile = o.Token, o.TokenEnv, o.TokenFile
+	}
+
+	if o.TLS.Insecure != nil {
+		s.TLS.Insecure = o.TLS.Insecure
+	}
+	if o.TLS.CAFile != "" {
+		s.TLS.CAFile = o.TLS.CAFile
+	}
+	if o.TLS.CertFile != "" {
+		s.TLS.CertFile = o.TLS.CertFile
+	}
+	if o.TLS.KeyFile != "" {
+		s.TLS.KeyFile = o.TLS.KeyFile
+	}
+	if o.TLS.ServerName != "" {
+		s.TLS.ServerName = o.TLS.ServerName
+	}
+
+	return s
+}
+
+// token returns the API key of resolved settings.
+func (inv *Inventory) token(s Settings) (string, error) {
+
+	switch {
+	case s.Token != "":
+		return s.Token, nil
+
+	case s.TokenEnv != "":
+		token := os.Getenv(s.TokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("%w: environment variable %s is not set", ErrMissingCredential, s.TokenEnv)
+		}
+		return token, nil
+
+	case s.TokenFile != "":
+		b, err := os.ReadFile(inv.path(s.TokenFile))
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrMissingCredential, err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	return "", fmt.Errorf("%w: set token, token_env or token_file", ErrMissingCredential)
+}
+
+// tlsConfig returns the TLS configuration of resolved settings, or nil if
+// there are none.
+func (inv *Inventory) tlsConfig(t TLS) (*tls.Config, error) {
+
+	if t == (TLS{}) {
+		return nil, nil
+	}
+
+	config := &tls.Config{ServerName: t.ServerName}
+
+	if t.Insecure != nil {
+		config.InsecureSkipVerify = *t.Insecure
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(inv.path(t.CAFile))
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found", t.CAFile)
+		}
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(inv.path(t.CertFile), inv.path(t.KeyFile))
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// path resolves a file path against the inventory directory.
+func (inv *Inventory) path(p string) string {
+
+	if p == "" || filepath.IsAbs(p) || inv.dir == "" {
+		return p
+	}
+
+	return filepath.Join(inv.dir, p)
+}
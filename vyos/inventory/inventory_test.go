@@ -0,0 +1,267 @@
+package inventory
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganawaj/go-vyos/vyos"
+)
+
+const testYAML = `
+defaults:
+  token: default-key
+fleet:
+  concurrency: 5
+  timeout: 30s
+groups:
+  lab:
+    tags: [test]
+    tls:
+      insecure: true
+  edge:
+    token_file: edge.key
+devices:
+  r1:
+    url: https://192.0.2.1/
+    groups: [lab, edge]
+    tags: [site-a]
+  r2:
+    url: https://192.0.2.2
+    token_env: INVENTORY_TEST_UNSET
+`
+
+const testJSON = `{
+  "defaults": {"token": "default-key"},
+  "fleet": {"concurrency": 5, "timeout": "30s"},
+  "groups": {
+    "lab": {"tags": ["test"], "tls": {"insecure": true}},
+    "edge": {"token_file": "edge.key"}
+  },
+  "devices": {
+    "r1": {"url": "https://192.0.2.1/", "groups": ["lab", "edge"], "tags": ["site-a"]},
+    "r2": {"url": "https://192.0.2.2", "token_env": "INVENTORY_TEST_UNSET"}
+  }
+}`
+
+const testTOML = `
+[defaults]
+token = "default-key"
+
+[fleet]
+concurrency = 5
+timeout = "30s"
+
+[groups.lab]
+tags = ["test"]
+tls = { insecure = true }
+
+[groups.edge]
+token_file = "edge.key"
+
+[devices.r1]
+url = "https://192.0.2.1/"
+groups = ["lab", "edge"]
+tags = ["site-a"]
+
+[devices.r2]
+url = "https://192.0.2.2"
+token_env = "INVENTORY_TEST_UNSET"
+`
+
+// TestLoad tests that the formats decode to the same inventory.
+func TestLoad(t *testing.T) {
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "edge.key"), []byte("edge-key\n"), 0o600)
+
+	insecure := true
+	wantR1 := Settings{
+		Tags:      []string{"lab", "test", "edge", "site-a"},
+		TokenFile: "edge.key",
+		TLS:       TLS{Insecure: &insecure},
+	}
+
+	for file, data := range map[string]string{"inventory.yaml": testYAML, "inventory.json": testJSON, "inventory.toml": testTOML} {
+
+		path := filepath.Join(dir, file)
+		os.WriteFile(path, []byte(data), 0o600)
+
+		inv, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) returned error: %v", file, err)
+		}
+
+		if got, want := inv.Names(), []string{"r1", "r2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s names are %v, want %v", file, got, want)
+		}
+
+		got, err := inv.Resolve("r1")
+		if err != nil {
+			t.Fatalf("%s Resolve returned error: %v", file, err)
+		}
+		if !reflect.DeepEqual(got, wantR1) {
+			t.Errorf("%s r1 settings are %+v, want %+v", file, got, wantR1)
+		}
+
+		c, err := inv.NewClient("r1")
+		if err != nil {
+			t.Fatalf("%s NewClient returned error: %v", file, err)
+		}
+		if c.BaseURL != "https://192.0.2.1" {
+			t.Errorf("%s BaseURL is %v, want %v", file, c.BaseURL, "https://192.0.2.1")
+		}
+		if c.Token != "edge-key" {
+			t.Errorf("%s Token is %v, want %v", file, c.Token, "edge-key")
+		}
+
+		if got := time.Duration(inv.Fleet.Timeout); got != 30*time.Second {
+			t.Errorf("%s fleet timeout is %v, want %v", file, got, 30*time.Second)
+		}
+
+		_, err = inv.NewClient("r2")
+		if !errors.Is(err, ErrMissingCredential) {
+			t.Errorf("%s r2 error is %v, want %v", file, err, ErrMissingCredential)
+		}
+	}
+}
+
+// TestParseErrors tests invalid inventories.
+func TestParseErrors(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		data string
+		want error
+	}{
+		{"devices:\n  r1:\n    groups: [x]\n    url: https://r1\n", ErrUnknownGroup},
+		{"devices:\n  r1:\n    token: x\n", ErrMissingURL},
+		{"defaults:\n  token: x\n  token_env: Y\n", ErrCredentialConflict},
+		{"groups:\n  lab:\n    token_env: Y\n    token_file: y.key\n", ErrCredentialConflict},
+		{"devices:\n  r1:\n    url: https://r1\n    tls:\n      key_file: r1.key\n", ErrMissingCertFile},
+	}
+
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.data), YAML); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) returned %v, want %v", tt.data, err, tt.want)
+		}
+	}
+
+	if _, err := Parse([]byte("devices:\n  r1:\n    address: x\n"), YAML); err == nil {
+		t.Error("Parse with an unknown field returned no error")
+	}
+	if _, err := Parse([]byte("[devices.r1]\nadress = \"x\"\n"), TOML); err == nil {
+		t.Error("Parse with an unknown TOML field returned no error")
+	}
+	if _, err := Load("inventory.ini"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Load returned %v, want %v", err, ErrUnknownFormat)
+	}
+}
+
+// TestResolveTLS tests that the TLS files are inherited one by one.
+func TestResolveTLS(t *testing.T) {
+
+	t.Parallel()
+
+	data := `
+defaults:
+  tls:
+    cert_file: default.pem
+    key_file: default.key
+devices:
+  r1:
+    url: https://r1
+    tls:
+      cert_file: r1.pem
+  r2:
+    url: https://r2
+    tls:
+      cert_file: r2.pem
+      key_file: r2.key
+`
+
+	inv, err := Parse([]byte(data), YAML)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	tests := map[string]TLS{
+		"r1": {CertFile: "r1.pem", KeyFile: "default.key"},
+		"r2": {CertFile: "r2.pem", KeyFile: "r2.key"},
+	}
+
+	for name, want := range tests {
+		s, err := inv.Resolve(name)
+		if err != nil {
+			t.Fatalf("Resolve(%s) returned error: %v", name, err)
+		}
+		if s.TLS != want {
+			t.Errorf("%s TLS is %+v, want %+v", name, s.TLS, want)
+		}
+	}
+}
+
+// TestNewFleet tests building a fleet that trusts the router CA.
+func TestNewFleet(t *testing.T) {
+
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success": true, "error": null, "data": "` + r.FormValue("key") + `"}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	os.WriteFile(filepath.Join(dir, "ca.pem"), ca, 0o600)
+
+	data := `{
+  "defaults": {"tls": {"ca_file": "ca.pem"}},
+  "fleet": {"concurrency": 2},
+  "groups": {"core": {}},
+  "devices": {
+    "r1": {"url": "` + srv.URL + `", "token": "k1", "groups": ["core"]},
+    "r2": {"url": "` + srv.URL + `", "token": "k2"}
+  }
+}`
+	path := filepath.Join(dir, "inventory.json")
+	os.WriteFile(path, []byte(data), 0o600)
+
+	inv, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	f, err := inv.NewFleet()
+	if err != nil {
+		t.Fatalf("NewFleet returned error: %v", err)
+	}
+	if f.Concurrency != 2 {
+		t.Errorf("Concurrency is %v, want %v", f.Concurrency, 2)
+	}
+	if got := f.Tagged("core").Len(); got != 1 {
+		t.Errorf("core devices are %v, want %v", got, 1)
+	}
+
+	results := f.Run(context.TODO(), func(ctx context.Context, d *vyos.Device) error {
+		out, _, err := d.Client.Show.Do(ctx, "version")
+		if err == nil && out.Data != d.Client.Token {
+			t.Errorf("%s data is %v, want %v", d.Name, out.Data, d.Client.Token)
+		}
+		return err
+	})
+	if err := results.Err(); err != nil {
+		t.Errorf("Run returned error: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package vyos
+
+import (
+	"context"
+	"sort"
+	"strconv"
+)
+
+// NATService manages source NAT, destination NAT and NAT66 rules using typed
+// models. It is built on top of ConfigService.
+type NATService service
+
+// NATTable is the configuration path of a NAT rule table.
+type NATTable string
+
+// NATTable constants
+const (
+	NATSource        NATTable = "nat source"
+	NATDestination   NATTable = "nat destination"
+	NAT66Source      NATTable = "nat66 source"
+	NAT66Destination NATTable = "nat66 destination"
+)
+
+// maxNATRule is the highest rule number accepted by VyOS.
+const maxNATRule = 999999
+
+// Path returns the configuration path of the table's rules.
+func (t NATTable) Path() Path {
+	return MustParsePath(string(t)).Append("rule")
+}
+
+// NATMatch is the source or destination criteria of a NAT rule.
+type NATMatch struct {
+	Address string                 `vyos:"address"`
+	Port    string                 `vyos:"port"`
+	Group   FirewallGroupMatch     `vyos:"group"`
+	Other   map[string]interface{} `vyos:",remain"`
+}
+
+// NATInterface matches the inbound or outbound interface of a NAT rule.
+type NATInterface struct {
+	Name  string `vyos:"name"`
+	Group string `vyos:"group"`
+}
+
+// NATTranslation is the translated address and port. For source NAT the
+// address can be `masquerade`.
+type NATTranslation struct {
+	Address string                 `vyos:"address"`
+	Port    string                 `vyos:"port"`
+	Other   map[string]interface{} `vyos:",remain"`
+}
+
+// NATRule is a source or destination NAT rule.
+type NATRule struct {
+	Number            int                    `vyos:"-"`
+	Description       string                 `vyos:"description"`
+	Protocol          string                 `vyos:"protocol"`
+	Source            NATMatch               `vyos:"source"`
+	Destination       NATMatch               `vyos:"destination"`
+	InboundInterface  NATInterface           `vyos:"inbound-interface"`
+	OutboundInterface NATInterface           `vyos:"outbound-interface"`
+	Translation       NATTranslation         `vyos:"translation"`
+	Exclude           bool                   `vyos:"exclude"`
+	Log               bool                   `vyos:"log"`
+	Disable           bool                   `vyos:"disable"`
+	Other             map[string]interface{} `vyos:",remain"`
+}
+
+// List returns the rules of a table, sorted by rule number.
+func (s *NATService) List(ctx context.Context, table NATTable) ([]NATRule, *Response, error) {
+
+	node, resp, err := s.client.Conf.getNode(ctx, table.Path())
+	if err != nil {
+		return nil, resp, err
+	}
+
+	var rules map[int]NATRule
+	if err := Unmarshal(node, &rules); err != nil {
+		return nil, resp, err
+	}
+
+	list := make([]NATRule, 0, len(rules))
+	for n, rule := range rules {
+		rule.Number = n
+		list = append(list, rule)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Number < list[j].Number
+	})
+
+	return list, resp, nil
+}
+
+// Get returns a single rule.
+func (s *NATService) Get(ctx context.Context, table NATTable, number int) (*NATRule, *Response, error) {
+
+	if number <= 0 {
+		return nil, nil, ErrInvalidRuleNumber
+	}
+
+	v := new(NATRule)
+	resp, err := GetPathInto(ctx, s.client.Conf, table.Path().Append(strconv.Itoa(number)), v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	v.Number = number
+
+	return v, resp, nil
+}
+
+// Upsert creates the rule, or replaces it if its number is already in use.
+// rule.Number must be set; see NextFreeRule to pick one.
+func (s *NATService) Upsert(ctx context.Context, table NATTable, rule NATRule) (*ConfigResponse, *Response, error) {
+
+	if rule.Number <= 0 || rule.Number > maxNATRule {
+		return nil, nil, ErrInvalidRuleNumber
+	}
+
+	return s.client.Conf.Replace(ctx, table.Path().Append(strconv.Itoa(rule.Number)), rule)
+}
+
+// Delete deletes the rule at number.
+func (s *NATService) Delete(ctx context.Context, table NATTable, number int) (*ConfigResponse, *Response, error) {
+
+	if number <= 0 {
+		return nil, nil, ErrInvalidRuleNumber
+	}
+
+	return s.client.Conf.DeletePath(ctx, table.Path().Append(strconv.Itoa(number)))
+}
+
+// NextFreeRule returns the first unused rule number at or after start,
+// stepping by step, e.g. NextFreeRule(ctx, NATDestination, 100, 10).
+func (s *NATService) NextFreeRule(ctx context.Context, table NATTable, start, step int) (int, *Response, error) {
+
+	rules, resp, err := s.client.Conf.getNode(ctx, table.Path())
+	if err != nil {
+		return 0, resp, err
+	}
+
+	n, err := nextFreeRule(rules, start, step, maxNATRule)
+
+	return n, resp, err
+}
@@ -0,0 +1,171 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestNAT tests listing, reading, writing and numbering NAT rules.
+func TestNAT(t *testing.T) {
+
+	t.Parallel()
+
+	var retrieved []Request
+	var configured json.RawMessage
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/configure" {
+			configured = json.RawMessage(r.FormValue("data"))
+			w.Write([]byte(`{"success": true, "data": null, "error": null}`))
+			return
+		}
+
+		var req Request
+		if err := json.Unmarshal([]byte(r.FormValue("data")), &req); err != nil {
+			t.Errorf("Error decoding request data: %v", err)
+		}
+		retrieved = append(retrieved, req)
+
+		switch {
+		case req.OPMode == "exists":
+			w.Write([]byte(`{"success": true, "data": true, "error": null}`))
+		case len(req.Path) == 4:
+			w.Write([]byte(`{"success": true, "error": null, "data": {
+				"description": "web",
+				"destination": {"port": "443"},
+				"inbound-interface": {"name": "eth0"},
+				"protocol": "tcp",
+				"translation": {"address": "192.0.2.10"}
+			}}`))
+		default:
+			w.Write([]byte(`{"success": true, "error": null, "data": {
+				"110": {"translation": {"address": "192.0.2.11"}},
+				"100": {"description": "web", "translation": {"address": "192.0.2.10"}}
+			}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	ctx := context.TODO()
+	base := P("nat", "destination", "rule")
+
+	// The requests share one server, so they run in order.
+	rules, _, err := c.NAT.List(ctx, NATDestination)
+	if err != nil {
+		t.Fatalf("NAT.List returned error: %v", err)
+	}
+
+	var numbers []int
+	for _, rule := range rules {
+		numbers = append(numbers, rule.Number)
+	}
+	if want := []int{100, 110}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("NAT.List numbers are %v, want %v", numbers, want)
+	}
+	if got, want := rules[1].Translation.Address, "192.0.2.11"; got != want {
+		t.Errorf("NAT.List rule 110 translation is %v, want %v", got, want)
+	}
+	if got, want := retrieved[len(retrieved)-1], (Request{OPMode: "showConfig", Path: base}); !reflect.DeepEqual(got, want) {
+		t.Errorf("NAT.List sent %v, want %v", got, want)
+	}
+
+	rule, _, err := c.NAT.Get(ctx, NATDestination, 100)
+	if err != nil {
+		t.Fatalf("NAT.Get returned error: %v", err)
+	}
+
+	want := &NATRule{
+		Number:           100,
+		Description:      "web",
+		Protocol:         "tcp",
+		Destination:      NATMatch{Port: "443"},
+		InboundInterface: NATInterface{Name: "eth0"},
+		Translation:      NATTranslation{Address: "192.0.2.10"},
+	}
+	if !reflect.DeepEqual(rule, want) {
+		t.Errorf("NAT.Get is %+v, want %+v", rule, want)
+	}
+	if got, want := retrieved[len(retrieved)-1].Path, base.Append("100"); !reflect.DeepEqual(got, want) {
+		t.Errorf("NAT.Get path is %v, want %v", got, want)
+	}
+
+	rule.Translation.Port = "8443"
+	if _, _, err := c.NAT.Upsert(ctx, NATDestination, *rule); err != nil {
+		t.Fatalf("NAT.Upsert returned error: %v", err)
+	}
+
+	var batch []Request
+	if err := json.Unmarshal(configured, &batch); err != nil {
+		t.Fatalf("Error decoding NAT.Upsert request: %v", err)
+	}
+	wantBatch := []Request{
+		{OPMode: OPModeDelete, Path: base.Append("100")},
+		{OPMode: OPModeSet, Path: base.Append("100", "description", "web")},
+		{OPMode: OPModeSet, Path: base.Append("100", "destination", "port", "443")},
+		{OPMode: OPModeSet, Path: base.Append("100", "inbound-interface", "name", "eth0")},
+		{OPMode: OPModeSet, Path: base.Append("100", "protocol", "tcp")},
+		{OPMode: OPModeSet, Path: base.Append("100", "translation", "address", "192.0.2.10")},
+		{OPMode: OPModeSet, Path: base.Append("100", "translation", "port", "8443")},
+	}
+	if !reflect.DeepEqual(batch, wantBatch) {
+		t.Errorf("NAT.Upsert sent %v, want %v", batch, wantBatch)
+	}
+
+	if _, _, err := c.NAT.Delete(ctx, NATDestination, 110); err != nil {
+		t.Fatalf("NAT.Delete returned error: %v", err)
+	}
+
+	var deleted Request
+	if err := json.Unmarshal(configured, &deleted); err != nil {
+		t.Fatalf("Error decoding NAT.Delete request: %v", err)
+	}
+	if want := (Request{OPMode: OPModeDelete, Path: base.Append("110")}); !reflect.DeepEqual(deleted, want) {
+		t.Errorf("NAT.Delete sent %v, want %v", deleted, want)
+	}
+
+	n, _, err := c.NAT.NextFreeRule(ctx, NATDestination, 100, 10)
+	if err != nil {
+		t.Fatalf("NAT.NextFreeRule returned error: %v", err)
+	}
+	if n != 120 {
+		t.Errorf("NAT.NextFreeRule is %v, want %v", n, 120)
+	}
+
+	// Invalid rule numbers are rejected before any request is sent.
+	if _, _, err := c.NAT.Get(ctx, NATDestination, 0); err != ErrInvalidRuleNumber {
+		t.Errorf("NAT.Get(0) returned %v, want %v", err, ErrInvalidRuleNumber)
+	}
+	if _, _, err := c.NAT.Upsert(ctx, NATSource, NATRule{Number: maxNATRule + 1}); err != ErrInvalidRuleNumber {
+		t.Errorf("NAT.Upsert returned %v, want %v", err, ErrInvalidRuleNumber)
+	}
+	if _, _, err := c.NAT.Delete(ctx, NATSource, -1); err != ErrInvalidRuleNumber {
+		t.Errorf("NAT.Delete(-1) returned %v, want %v", err, ErrInvalidRuleNumber)
+	}
+}
+
+// TestNATTablePath tests the rule paths of the NAT tables.
+func TestNATTablePath(t *testing.T) {
+
+	t.Parallel()
+
+	tests := map[NATTable]Path{
+		NATSource:        {"nat", "source", "rule"},
+		NATDestination:   {"nat", "destination", "rule"},
+		NAT66Source:      {"nat66", "source", "rule"},
+		NAT66Destination: {"nat66", "destination", "rule"},
+	}
+
+	for table, want := range tests {
+		if got := table.Path(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s Path is %v, want %v", table, got, want)
+		}
+	}
+}
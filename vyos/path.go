@@ -0,0 +1,139 @@
+package vyos
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrUnterminatedQuote = errors.New("path contains an unterminated quote")
+	ErrTrailingEscape    = errors.New("path ends with an unfinished escape")
+)
+
+// P returns a Path made of the given elements. Elements are used verbatim, so
+// values containing spaces do not need to be quoted:
+//
+//	vyos.P("interfaces", "ethernet", "eth0", "description", "Uplink to ISP")
+func P(elems ...string) Path {
+	return append(Path(nil), elems...)
+}
+
+// ParsePath parses a path written the way it would be typed on the VyOS CLI.
+// Elements are separated by whitespace; single quotes, double quotes and
+// backslash escapes can be used to keep spaces inside an element:
+//
+//	interfaces ethernet eth0 description 'Uplink to ISP'
+//
+// An empty or blank string returns an empty path.
+func ParsePath(s string) (Path, error) {
+
+	var (
+		p       Path
+		elem    strings.Builder
+		inElem  bool // Whether an element has been started, even if empty ('').
+		quote   rune // The quote currently open, if any.
+		escaped bool
+	)
+
+	for _, r := range s {
+
+		switch {
+		case escaped:
+			elem.WriteRune(r)
+			escaped = false
+
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				elem.WriteRune(r)
+			}
+
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				elem.WriteRune(r)
+			}
+
+		case r == '\'' || r == '"':
+			quote = r
+			inElem = true
+
+		case r == '\\':
+			escaped = true
+			inElem = true
+
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inElem {
+				p = append(p, elem.String())
+				elem.Reset()
+				inElem = false
+			}
+
+		default:
+			elem.WriteRune(r)
+			inElem = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, ErrUnterminatedQuote
+	}
+
+	if escaped {
+		return nil, ErrTrailingEscape
+	}
+
+	if inElem {
+		p = append(p, elem.String())
+	}
+
+	return p, nil
+}
+
+// MustParsePath is like ParsePath but panics if the path cannot be parsed.
+func MustParsePath(s string) Path {
+
+	p, err := ParsePath(s)
+	if err != nil {
+		panic(`ParsePath(` + s + `): ` + err.Error())
+	}
+
+	return p
+}
+
+// Append returns a new path with elems appended to p.
+func (p Path) Append(elems ...string) Path {
+
+	n := make(Path, 0, len(p)+len(elems))
+	n = append(n, p...)
+
+	return append(n, elems...)
+}
+
+// String returns the path as it would be typed on the VyOS CLI, quoting
+// elements where needed. The result can be parsed back with ParsePath.
+func (p Path) String() string {
+
+	elems := make([]string, len(p))
+	for i, e := range p {
+		elems[i] = quoteElem(e)
+	}
+
+	return strings.Join(elems, " ")
+}
+
+// quoteElem wraps an element in single quotes if it contains characters that
+// ParsePath would otherwise interpret.
+func quoteElem(e string) string {
+
+	if e != "" && !strings.ContainsAny(e, " \t\r\n'\"\\") {
+		return e
+	}
+
+	return "'" + strings.ReplaceAll(e, "'", `'\''`) + "'"
+}
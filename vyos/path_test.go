@@ -0,0 +1,69 @@
+package vyos
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParsePath tests parsing of CLI style paths.
+func TestParsePath(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want Path
+		err  error
+	}{
+		{"", nil, nil},
+		{"   ", nil, nil},
+		{"show version", Path{"show", "version"}, nil},
+		{"system  host-name   r1", Path{"system", "host-name", "r1"}, nil},
+		{"interfaces ethernet eth0 description 'Uplink to ISP'", Path{"interfaces", "ethernet", "eth0", "description", "Uplink to ISP"}, nil},
+		{`system login banner pre-login "Authorised \"staff\" only"`, Path{"system", "login", "banner", "pre-login", `Authorised "staff" only`}, nil},
+		{`firewall description It\'s\ fine`, Path{"firewall", "description", "It's fine"}, nil},
+		{"system name-server ''", Path{"system", "name-server", ""}, nil},
+		{"description 'unterminated", nil, ErrUnterminatedQuote},
+		{`description trailing\`, nil, ErrTrailingEscape},
+	}
+
+	for _, tt := range tests {
+
+		got, err := ParsePath(tt.in)
+		if err != tt.err {
+			t.Errorf("ParsePath(%q) returned error %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePath(%q) is %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestPathStringRoundTrip tests that Path.String output parses back unchanged.
+func TestPathStringRoundTrip(t *testing.T) {
+
+	t.Parallel()
+
+	paths := []Path{
+		P("interfaces", "ethernet", "eth0", "address", "192.0.2.1/24"),
+		P("interfaces", "ethernet", "eth0", "description", "Uplink to ISP"),
+		P("system", "login", "user", "vyos", "authentication", "public-keys", "a", "key", "AAAA B3Nz"),
+		P("firewall", "description", `it's "quoted" \ here`),
+		P("system", "name-server", ""),
+	}
+
+	for _, p := range paths {
+
+		got, err := ParsePath(p.String())
+		if err != nil {
+			t.Errorf("ParsePath(%q) returned error: %v", p.String(), err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("ParsePath(%q) is %#v, want %#v", p.String(), got, p)
+		}
+	}
+}
@@ -2,6 +2,17 @@ package vyos
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidDelay  = errors.New("delay must be at least one minute")
+	ErrRebootTimeout = errors.New("timed out waiting for reboot")
 )
 
 // Response represents a response from the VyOS API.
@@ -14,44 +25,126 @@ type PowerResponse struct {
 
 // PowerOff sends a request to the VyOS API to power off the system.
 func (s *PowerService) PowerOff(ctx context.Context) (*PowerResponse, *Response, error) {
+	return s.power(ctx, "/poweroff", "poweroff", Path{"now"})
+}
 
-	u := "/poweroff"
+// PowerOffIn schedules a power off in the given number of minutes.
+func (s *PowerService) PowerOffIn(ctx context.Context, minutes int) (*PowerResponse, *Response, error) {
 
-	// Create a new request.
-	request := Request{
-		OPMode: "poweroff",
-		Path:   []string{"now"},
+	if minutes < 1 {
+		return nil, nil, ErrInvalidDelay
 	}
 
-	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
-	if err != nil {
-		return nil, nil, err
-	}
+	return s.power(ctx, "/poweroff", "poweroff", Path{"in", strconv.Itoa(minutes)})
+}
 
-	// Create the Response struct & send the request.
-	v := new(PowerResponse)
-	resp, err := s.client.Do(ctx, req, v)
-	if err != nil {
-		return nil, resp, err
-	}
+// PowerOffAt schedules a power off at the given time, in the time zone of
+// the router. If t has no date (year zero, as returned by time.Parse for a
+// layout such as "15:04"), the router picks the next time the clock reads t.
+func (s *PowerService) PowerOffAt(ctx context.Context, t time.Time) (*PowerResponse, *Response, error) {
+	return s.power(ctx, "/poweroff", "poweroff", atPath(t))
+}
 
-	return v, resp, nil
+// CancelPowerOff cancels a scheduled power off.
+func (s *PowerService) CancelPowerOff(ctx context.Context) (*PowerResponse, *Response, error) {
+	return s.power(ctx, "/poweroff", "poweroff", Path{"cancel"})
 }
 
 // Reboot sends a request to the VyOS API to reboot the system.
 func (s *PowerService) Reboot(ctx context.Context) (*PowerResponse, *Response, error) {
+	return s.power(ctx, "/reboot", "reboot", Path{"now"})
+}
+
+// RebootIn schedules a reboot in the given number of minutes.
+func (s *PowerService) RebootIn(ctx context.Context, minutes int) (*PowerResponse, *Response, error) {
+
+	if minutes < 1 {
+		return nil, nil, ErrInvalidDelay
+	}
+
+	return s.power(ctx, "/reboot", "reboot", Path{"in", strconv.Itoa(minutes)})
+}
+
+// RebootAt schedules a reboot at the given time, in the time zone of the
+// router. If t has no date (year zero, as returned by time.Parse for a
+// layout such as "15:04"), the router picks the next time the clock reads t.
+func (s *PowerService) RebootAt(ctx context.Context, t time.Time) (*PowerResponse, *Response, error) {
+	return s.power(ctx, "/reboot", "reboot", atPath(t))
+}
+
+// CancelReboot cancels a scheduled reboot.
+func (s *PowerService) CancelReboot(ctx context.Context) (*PowerResponse, *Response, error) {
+	return s.power(ctx, "/reboot", "reboot", Path{"cancel"})
+}
+
+// WaitForReboot polls the API every interval until connections to it fail
+// and then it responds again. Polls that time out are repeated without
+// counting the router as down. Bound the wait with a deadline on ctx; when it passes
+// an error wrapping ErrRebootTimeout is returned.
+func (s *PowerService) WaitForReboot(ctx context.Context, interval time.Duration) error {
 
-	u := "/reboot"
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	down := false
+	for {
+
+		if err := sleep(ctx, interval); err != nil {
+			return fmt.Errorf("%w: %w", ErrRebootTimeout, err)
+		}
+
+		// Each poll is bounded by the interval, as requests to a rebooting
+		// router often hang instead of failing; the next poll tells more.
+		pollCtx, cancel := context.WithTimeout(ctx, interval)
+		_, err := showVersion(pollCtx, s.client)
+		cancel()
+
+		switch {
+		case err == nil:
+			if down {
+				return nil
+			}
+		case routerDown(err):
+			down = true
+		}
+	}
+}
+
+// routerDown reports whether a failed poll shows the router is down: the
+// connection failed, or the proxy in front of the API could not reach it.
+// A poll that only ran out of time says nothing either way, as a busy router
+// can be slow to answer before it reboots.
+func routerDown(err error) bool {
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	return transientError(err)
+}
+
+// power sends a request to a power endpoint.
+func (s *PowerService) power(ctx context.Context, u string, op OPMode, path Path) (*PowerResponse, *Response, error) {
 
 	// Create a new request.
 	request := Request{
-		OPMode: "reboot",
-		Path:   []string{"now"},
+		OPMode: op,
+		Path:   path,
 	}
 
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, &request)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -66,6 +159,16 @@ func (s *PowerService) Reboot(ctx context.Context) (*PowerResponse, *Response, e
 	return v, resp, nil
 }
 
+// atPath returns the path for `at <HH:MM> date <DDMMYYYY>`, leaving out the
+// date if t has none.
+func atPath(t time.Time) Path {
+
+	if t.Year() == 0 {
+		return Path{"at", t.Format("15:04")}
+	}
+
+	return Path{"at", t.Format("15:04"), "date", t.Format("02012006")}
+}
 
 // PowerOff is a helper function to power off the VyOS instance from the client struct.
 func (s *Client) PowerOff(ctx context.Context) (*PowerResponse, *Response, error) {
@@ -75,4 +178,4 @@ func (s *Client) PowerOff(ctx context.Context) (*PowerResponse, *Response, error
 // Reboot is a helper function to reboot the VyOS instance from the client struct.
 func (s *Client) Reboot(ctx context.Context) (*PowerResponse, *Response, error) {
 	return s.Power.Reboot(ctx)
-}
\ No newline at end of file
+}
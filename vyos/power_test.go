@@ -0,0 +1,145 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestPowerSchedule tests the paths sent for scheduled power operations.
+func TestPowerSchedule(t *testing.T) {
+
+	t.Parallel()
+
+	var gotURL string
+	var got Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.Path
+		got = Request{}
+		json.Unmarshal([]byte(r.FormValue("data")), &got)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success": true, "error": null, "data": ""}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	at := time.Date(2024, time.March, 5, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) (*PowerResponse, *Response, error)
+		url  string
+		want Request
+	}{
+		{"reboot in", func(ctx context.Context) (*PowerResponse, *Response, error) { return c.Power.RebootIn(ctx, 10) },
+			"/reboot", Request{OPMode: "reboot", Path: Path{"in", "10"}}},
+		{"reboot at", func(ctx context.Context) (*PowerResponse, *Response, error) { return c.Power.RebootAt(ctx, at) },
+			"/reboot", Request{OPMode: "reboot", Path: Path{"at", "23:30", "date", "05032024"}}},
+		{"reboot at clock", func(ctx context.Context) (*PowerResponse, *Response, error) {
+			return c.Power.RebootAt(ctx, time.Date(0, time.January, 1, 23, 30, 0, 0, time.UTC))
+		}, "/reboot", Request{OPMode: "reboot", Path: Path{"at", "23:30"}}},
+		{"cancel reboot", c.Power.CancelReboot,
+			"/reboot", Request{OPMode: "reboot", Path: Path{"cancel"}}},
+		{"poweroff in", func(ctx context.Context) (*PowerResponse, *Response, error) { return c.Power.PowerOffIn(ctx, 5) },
+			"/poweroff", Request{OPMode: "poweroff", Path: Path{"in", "5"}}},
+		{"cancel poweroff", c.Power.CancelPowerOff,
+			"/poweroff", Request{OPMode: "poweroff", Path: Path{"cancel"}}},
+	}
+
+	// The requests share one server, so they run in order.
+	for _, tt := range tests {
+		if _, _, err := tt.call(context.TODO()); err != nil {
+			t.Fatalf("%s returned error: %v", tt.name, err)
+		}
+		if gotURL != tt.url {
+			t.Errorf("%s URL is %v, want %v", tt.name, gotURL, tt.url)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s request is %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, _, err := c.Power.RebootIn(context.TODO(), 0); !errors.Is(err, ErrInvalidDelay) {
+		t.Errorf("RebootIn(0) returned %v, want %v", err, ErrInvalidDelay)
+	}
+}
+
+// handlerTransport serves requests with a handler in the calling goroutine.
+// Unlike a test server, a response is never lost to a request deadline, so
+// tests polling with short intervals see every response. A handler that
+// writes nothing models a request that got no answer: it fails with the
+// context error.
+type handlerTransport struct {
+	http.Handler
+}
+
+func (t handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+
+	w := httptest.NewRecorder()
+	t.ServeHTTP(w, r)
+
+	if w.Code == http.StatusOK && len(w.Header()) == 0 && w.Body.Len() == 0 {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	}
+
+	return w.Result(), nil
+}
+
+// TestWaitForReboot tests waiting for the API to go down and come back.
+func TestWaitForReboot(t *testing.T) {
+
+	t.Parallel()
+
+	polls := 0
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success": true, "error": null, "data": "Version:          VyOS 1.4.0"}`))
+	})
+
+	c := NewClient(&http.Client{Transport: handlerTransport{router}}).WithURL("http://router").WithToken("test")
+	if err := c.Power.WaitForReboot(context.TODO(), time.Millisecond); err != nil {
+		t.Fatalf("WaitForReboot returned error: %v", err)
+	}
+
+	if got, want := polls, 3; got != want {
+		t.Errorf("polls is %v, want %v", got, want)
+	}
+}
+
+// TestWaitForRebootSlowPoll tests that a poll running out of time does not
+// count as the router going down.
+func TestWaitForRebootSlowPoll(t *testing.T) {
+
+	t.Parallel()
+
+	polls := 0
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first poll hangs until the client gives up on it.
+		polls++
+		if polls == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success": true, "error": null, "data": "Version:          VyOS 1.4.0"}`))
+	})
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	c := NewClient(&http.Client{Transport: handlerTransport{router}}).WithURL("http://router").WithToken("test")
+	if err := c.Power.WaitForReboot(ctx, time.Millisecond); !errors.Is(err, ErrRebootTimeout) {
+		t.Errorf("WaitForReboot returned %v, want %v", err, ErrRebootTimeout)
+	}
+}
@@ -0,0 +1,310 @@
+package vyos
+
+import (
+	"context"
+	"strings"
+)
+
+// ProtocolsService manages static routes, BGP and OSPF under `protocols`
+// using typed models. It is built on top of ConfigService.
+type ProtocolsService service
+
+// StaticNextHop is a gateway of a static route, keyed by address.
+type StaticNextHop struct {
+	Distance  int                    `vyos:"distance"`
+	Interface string                 `vyos:"interface"`
+	VRF       string                 `vyos:"vrf"`
+	Disable   bool                   `vyos:"disable"`
+	Other     map[string]interface{} `vyos:",remain"`
+}
+
+// StaticInterfaceRoute routes a prefix out of an interface, keyed by interface name.
+type StaticInterfaceRoute struct {
+	Distance int                    `vyos:"distance"`
+	VRF      string                 `vyos:"vrf"`
+	Disable  bool                   `vyos:"disable"`
+	Other    map[string]interface{} `vyos:",remain"`
+}
+
+// StaticBlackhole silently discards traffic to a prefix.
+type StaticBlackhole struct {
+	Distance int                    `vyos:"distance"`
+	Tag      int                    `vyos:"tag"`
+	Other    map[string]interface{} `vyos:",remain"`
+}
+
+// StaticRoute is a static route, keyed by destination prefix.
+type StaticRoute struct {
+	Description string                          `vyos:"description"`
+	NextHop     map[string]StaticNextHop        `vyos:"next-hop"`
+	Interface   map[string]StaticInterfaceRoute `vyos:"interface"`
+	Blackhole   *StaticBlackhole                `vyos:"blackhole"`
+	Other       map[string]interface{}          `vyos:",remain"`
+}
+
+// Static is the `protocols static` subtree.
+type Static struct {
+	Route  map[string]StaticRoute `vyos:"route"`
+	Route6 map[string]StaticRoute `vyos:"route6"`
+	Other  map[string]interface{} `vyos:",remain"`
+}
+
+// BGPPolicy names the import and export policy of a neighbor address family.
+type BGPPolicy struct {
+	Import string `vyos:"import"`
+	Export string `vyos:"export"`
+}
+
+// BGPNeighborFamily holds the per address family options of a neighbor.
+type BGPNeighborFamily struct {
+	RouteMap    BGPPolicy              `vyos:"route-map"`
+	PrefixList  BGPPolicy              `vyos:"prefix-list"`
+	NexthopSelf bool                   `vyos:"nexthop-self"`
+	Other       map[string]interface{} `vyos:",remain"`
+}
+
+// BGPNeighborFamilies is the `address-family` node of a neighbor.
+type BGPNeighborFamilies struct {
+	IPv4Unicast *BGPNeighborFamily     `vyos:"ipv4-unicast"`
+	IPv6Unicast *BGPNeighborFamily     `vyos:"ipv6-unicast"`
+	Other       map[string]interface{} `vyos:",remain"`
+}
+
+// BGPNeighbor is a BGP neighbor, keyed by address or interface. It is also
+// used for peer groups, keyed by group name.
+type BGPNeighbor struct {
+	RemoteAS      string                 `vyos:"remote-as"`
+	Description   string                 `vyos:"description"`
+	PeerGroup     string                 `vyos:"peer-group"`
+	UpdateSource  string                 `vyos:"update-source"`
+	EBGPMultihop  int                    `vyos:"ebgp-multihop"`
+	Password      string                 `vyos:"password"`
+	Shutdown      bool                   `vyos:"shutdown"`
+	AddressFamily BGPNeighborFamilies    `vyos:"address-family"`
+	Other         map[string]interface{} `vyos:",remain"`
+}
+
+// BGPNetwork is a prefix announced by BGP, keyed by prefix.
+type BGPNetwork struct {
+	RouteMap string                 `vyos:"route-map"`
+	Other    map[string]interface{} `vyos:",remain"`
+}
+
+// BGPRedistribute redistributes routes from another source, keyed by
+// source, e.g. connected or static.
+type BGPRedistribute struct {
+	RouteMap string                 `vyos:"route-map"`
+	Metric   int                    `vyos:"metric"`
+	Other    map[string]interface{} `vyos:",remain"`
+}
+
+// BGPFamily holds the global options of an address family.
+type BGPFamily struct {
+	Network      map[string]BGPNetwork      `vyos:"network"`
+	Redistribute map[string]BGPRedistribute `vyos:"redistribute"`
+	Other        map[string]interface{}     `vyos:",remain"`
+}
+
+// BGPFamilies is the global `address-family` node.
+type BGPFamilies struct {
+	IPv4Unicast *BGPFamily             `vyos:"ipv4-unicast"`
+	IPv6Unicast *BGPFamily             `vyos:"ipv6-unicast"`
+	Other       map[string]interface{} `vyos:",remain"`
+}
+
+// BGPParameters holds the global BGP parameters.
+type BGPParameters struct {
+	RouterID string                 `vyos:"router-id"`
+	Other    map[string]interface{} `vyos:",remain"`
+}
+
+// BGP is the `protocols bgp` subtree.
+type BGP struct {
+	SystemAS      string                 `vyos:"system-as"`
+	Parameters    BGPParameters          `vyos:"parameters"`
+	Neighbor      map[string]BGPNeighbor `vyos:"neighbor"`
+	PeerGroup     map[string]BGPNeighbor `vyos:"peer-group"`
+	AddressFamily BGPFamilies            `vyos:"address-family"`
+	Other         map[string]interface{} `vyos:",remain"`
+}
+
+// OSPFArea is an OSPF area, keyed by area ID.
+type OSPFArea struct {
+	Network []string               `vyos:"network"`
+	Other   map[string]interface{} `vyos:",remain"`
+}
+
+// OSPFInterface holds the OSPF options of an interface, keyed by interface name.
+type OSPFInterface struct {
+	Area          string                 `vyos:"area"`
+	Cost          int                    `vyos:"cost"`
+	Network       string                 `vyos:"network"`
+	HelloInterval int                    `vyos:"hello-interval"`
+	DeadInterval  int                    `vyos:"dead-interval"`
+	Passive       bool                   `vyos:"passive"`
+	Other         map[string]interface{} `vyos:",remain"`
+}
+
+// OSPFRedistribute redistributes routes from another source, keyed by
+// source, e.g. connected or static.
+type OSPFRedistribute struct {
+	Metric     int                    `vyos:"metric"`
+	MetricType int                    `vyos:"metric-type"`
+	RouteMap   string                 `vyos:"route-map"`
+	Other      map[string]interface{} `vyos:",remain"`
+}
+
+// OSPFParameters holds the global OSPF parameters.
+type OSPFParameters struct {
+	RouterID string                 `vyos:"router-id"`
+	Other    map[string]interface{} `vyos:",remain"`
+}
+
+// OSPF is the `protocols ospf` subtree.
+type OSPF struct {
+	Area         map[string]OSPFArea         `vyos:"area"`
+	Interface    map[string]OSPFInterface    `vyos:"interface"`
+	Parameters   OSPFParameters              `vyos:"parameters"`
+	Redistribute map[string]OSPFRedistribute `vyos:"redistribute"`
+	Other        map[string]interface{}      `vyos:",remain"`
+}
+
+// GetStatic returns the static routes.
+func (s *ProtocolsService) GetStatic(ctx context.Context) (*Static, *Response, error) {
+	v := new(Static)
+	resp, err := s.get(ctx, P("protocols", "static"), v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return v, resp, nil
+}
+
+// ApplyStatic replaces all static routes with static. A nil static deletes
+// them, as RemoveStatic does.
+func (s *ProtocolsService) ApplyStatic(ctx context.Context, static *Static) (*ConfigResponse, *Response, error) {
+
+	if static == nil {
+		return s.RemoveStatic(ctx)
+	}
+
+	return s.client.Conf.Replace(ctx, P("protocols", "static"), static)
+}
+
+// RemoveStatic deletes all static routes.
+func (s *ProtocolsService) RemoveStatic(ctx context.Context) (*ConfigResponse, *Response, error) {
+	return s.client.Conf.DeletePath(ctx, P("protocols", "static"))
+}
+
+// ApplyStaticRoute creates or replaces the static route to prefix. IPv6
+// prefixes are written under route6.
+func (s *ProtocolsService) ApplyStaticRoute(ctx context.Context, prefix string, route StaticRoute) (*ConfigResponse, *Response, error) {
+
+	if prefix == "" {
+		return nil, nil, ErrEmptyPath
+	}
+
+	return s.client.Conf.Replace(ctx, staticRoutePath(prefix), route)
+}
+
+// RemoveStaticRoute deletes the static route to prefix.
+func (s *ProtocolsService) RemoveStaticRoute(ctx context.Context, prefix string) (*ConfigResponse, *Response, error) {
+
+	if prefix == "" {
+		return nil, nil, ErrEmptyPath
+	}
+
+	return s.client.Conf.DeletePath(ctx, staticRoutePath(prefix))
+}
+
+// GetBGP returns the BGP configuration.
+func (s *ProtocolsService) GetBGP(ctx context.Context) (*BGP, *Response, error) {
+	v := new(BGP)
+	resp, err := s.get(ctx, P("protocols", "bgp"), v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return v, resp, nil
+}
+
+// ApplyBGP replaces the BGP configuration with bgp. A nil bgp deletes
+// it, as RemoveBGP does.
+func (s *ProtocolsService) ApplyBGP(ctx context.Context, bgp *BGP) (*ConfigResponse, *Response, error) {
+
+	if bgp == nil {
+		return s.RemoveBGP(ctx)
+	}
+
+	return s.client.Conf.Replace(ctx, P("protocols", "bgp"), bgp)
+}
+
+// RemoveBGP deletes the BGP configuration.
+func (s *ProtocolsService) RemoveBGP(ctx context.Context) (*ConfigResponse, *Response, error) {
+	return s.client.Conf.DeletePath(ctx, P("protocols", "bgp"))
+}
+
+// ApplyBGPNeighbor creates or replaces a single BGP neighbor.
+func (s *ProtocolsService) ApplyBGPNeighbor(ctx context.Context, address string, neighbor BGPNeighbor) (*ConfigResponse, *Response, error) {
+
+	if address == "" {
+		return nil, nil, ErrEmptyPath
+	}
+
+	return s.client.Conf.Replace(ctx, P("protocols", "bgp", "neighbor", address), neighbor)
+}
+
+// RemoveBGPNeighbor deletes a single BGP neighbor.
+func (s *ProtocolsService) RemoveBGPNeighbor(ctx context.Context, address string) (*ConfigResponse, *Response, error) {
+
+	if address == "" {
+		return nil, nil, ErrEmptyPath
+	}
+
+	return s.client.Conf.DeletePath(ctx, P("protocols", "bgp", "neighbor", address))
+}
+
+// GetOSPF returns the OSPF configuration.
+func (s *ProtocolsService) GetOSPF(ctx context.Context) (*OSPF, *Response, error) {
+	v := new(OSPF)
+	resp, err := s.get(ctx, P("protocols", "ospf"), v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return v, resp, nil
+}
+
+// ApplyOSPF replaces the OSPF configuration with ospf. A nil ospf deletes
+// it, as RemoveOSPF does.
+func (s *ProtocolsService) ApplyOSPF(ctx context.Context, ospf *OSPF) (*ConfigResponse, *Response, error) {
+
+	if ospf == nil {
+		return s.RemoveOSPF(ctx)
+	}
+
+	return s.client.Conf.Replace(ctx, P("protocols", "ospf"), ospf)
+}
+
+// RemoveOSPF deletes the OSPF configuration.
+func (s *ProtocolsService) RemoveOSPF(ctx context.Context) (*ConfigResponse, *Response, error) {
+	return s.client.Conf.DeletePath(ctx, P("protocols", "ospf"))
+}
+
+// get decodes the node at path into v. A missing node leaves v empty.
+func (s *ProtocolsService) get(ctx context.Context, path Path, v interface{}) (*Response, error) {
+
+	node, resp, err := s.client.Conf.getNode(ctx, path)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, Unmarshal(node, v)
+}
+
+// staticRoutePath returns the path of a static route to prefix.
+func staticRoutePath(prefix string) Path {
+
+	if strings.Contains(prefix, ":") {
+		return P("protocols", "static", "route6", prefix)
+	}
+
+	return P("protocols", "static", "route", prefix)
+}
@@ -0,0 +1,316 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestStaticSetPaths tests the set paths generated for static routes.
+func TestStaticSetPaths(t *testing.T) {
+
+	t.Parallel()
+
+	static := &Static{
+		Route: map[string]StaticRoute{
+			"0.0.0.0/0": {
+				NextHop: map[string]StaticNextHop{"192.0.2.1": {Distance: 10}},
+			},
+			"10.0.0.0/8": {
+				Blackhole: &StaticBlackhole{},
+			},
+		},
+		Route6: map[string]StaticRoute{
+			"2001:db8::/32": {
+				Interface: map[string]StaticInterfaceRoute{"wg0": {}},
+			},
+		},
+	}
+
+	tree, err := Marshal(static)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+
+	base := P("protocols", "static")
+	want := []Path{
+		base.Append("route", "0.0.0.0/0", "next-hop", "192.0.2.1", "distance", "10"),
+		base.Append("route", "10.0.0.0/8", "blackhole"),
+		base.Append("route6", "2001:db8::/32", "interface", "wg0"),
+	}
+
+	if got := SetPaths(base, tree); !reflect.DeepEqual(got, want) {
+		t.Errorf("SetPaths is %v, want %v", got, want)
+	}
+
+	if got, want := staticRoutePath("2001:db8::/32"), base.Append("route6", "2001:db8::/32"); !reflect.DeepEqual(got, want) {
+		t.Errorf("staticRoutePath is %v, want %v", got, want)
+	}
+}
+
+// newProtocolsServer returns a server answering showConfig requests for
+// `protocols bgp` and `protocols ospf`, and storing the data of the last
+// configure request in configured.
+func newProtocolsServer(t *testing.T, configured *json.RawMessage) *httptest.Server {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/configure" {
+			*configured = json.RawMessage(r.FormValue("data"))
+			w.Write([]byte(`{"success": true, "data": null, "error": null}`))
+			return
+		}
+
+		var req Request
+		if err := json.Unmarshal([]byte(r.FormValue("data")), &req); err != nil {
+			t.Errorf("Error decoding request data: %v", err)
+		}
+
+		switch {
+		case req.OPMode == "exists":
+			w.Write([]byte(`{"success": true, "data": true, "error": null}`))
+		case reflect.DeepEqual(req.Path, P("protocols", "bgp")):
+			w.Write([]byte(`{"success": true, "error": null, "data": {
+				"system-as": "65001",
+				"parameters": {"router-id": "192.0.2.1"},
+				"neighbor": {
+					"192.0.2.2": {
+						"remote-as": "65002",
+						"address-family": {"ipv4-unicast": {"route-map": {"import": "IN", "export": "OUT"}}}
+					},
+					"eth1": {"peer-group": "FABRIC"}
+				},
+				"peer-group": {"FABRIC": {"remote-as": "external"}},
+				"address-family": {"ipv4-unicast": {"network": {"198.51.100.0/24": {}}}}
+			}}`))
+		case reflect.DeepEqual(req.Path, P("protocols", "ospf")):
+			w.Write([]byte(`{"success": true, "error": null, "data": {
+				"area": {
+					"0": {"network": ["10.0.0.0/24", "10.0.1.0/24"]},
+					"1": {"network": "10.1.0.0/24"}
+				},
+				"interface": {"eth0": {"cost": "10", "passive": {}}},
+				"parameters": {"router-id": "192.0.2.1"}
+			}}`))
+		default:
+			t.Errorf("Unexpected request %v", req)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// TestBGP tests reading and writing the BGP configuration.
+func TestBGP(t *testing.T) {
+
+	t.Parallel()
+
+	var configured json.RawMessage
+	srv := newProtocolsServer(t, &configured)
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	ctx := context.TODO()
+	base := P("protocols", "bgp")
+
+	// The requests share one server, so they run in order.
+	bgp, _, err := c.Protocols.GetBGP(ctx)
+	if err != nil {
+		t.Fatalf("Protocols.GetBGP returned error: %v", err)
+	}
+
+	want := &BGP{
+		SystemAS:   "65001",
+		Parameters: BGPParameters{RouterID: "192.0.2.1"},
+		Neighbor: map[string]BGPNeighbor{
+			"192.0.2.2": {
+				RemoteAS: "65002",
+				AddressFamily: BGPNeighborFamilies{
+					IPv4Unicast: &BGPNeighborFamily{RouteMap: BGPPolicy{Import: "IN", Export: "OUT"}},
+				},
+			},
+			"eth1": {PeerGroup: "FABRIC"},
+		},
+		PeerGroup: map[string]BGPNeighbor{"FABRIC": {RemoteAS: "external"}},
+		AddressFamily: BGPFamilies{
+			IPv4Unicast: &BGPFamily{Network: map[string]BGPNetwork{"198.51.100.0/24": {}}},
+		},
+	}
+	if !reflect.DeepEqual(bgp, want) {
+		t.Errorf("Protocols.GetBGP is %+v, want %+v", bgp, want)
+	}
+
+	if _, _, err := c.Protocols.ApplyBGP(ctx, bgp); err != nil {
+		t.Fatalf("Protocols.ApplyBGP returned error: %v", err)
+	}
+
+	var batch []Request
+	if err := json.Unmarshal(configured, &batch); err != nil {
+		t.Fatalf("Error decoding Protocols.ApplyBGP request: %v", err)
+	}
+	wantBatch := []Request{
+		{OPMode: OPModeDelete, Path: base},
+		{OPMode: OPModeSet, Path: base.Append("address-family", "ipv4-unicast", "network", "198.51.100.0/24")},
+		{OPMode: OPModeSet, Path: base.Append("neighbor", "192.0.2.2", "address-family", "ipv4-unicast", "route-map", "export", "OUT")},
+		{OPMode: OPModeSet, Path: base.Append("neighbor", "192.0.2.2", "address-family", "ipv4-unicast", "route-map", "import", "IN")},
+		{OPMode: OPModeSet, Path: base.Append("neighbor", "192.0.2.2", "remote-as", "65002")},
+		{OPMode: OPModeSet, Path: base.Append("neighbor", "eth1", "peer-group", "FABRIC")},
+		{OPMode: OPModeSet, Path: base.Append("parameters", "router-id", "192.0.2.1")},
+		{OPMode: OPModeSet, Path: base.Append("peer-group", "FABRIC", "remote-as", "external")},
+		{OPMode: OPModeSet, Path: base.Append("system-as", "65001")},
+	}
+	if !reflect.DeepEqual(batch, wantBatch) {
+		t.Errorf("Protocols.ApplyBGP sent %v, want %v", batch, wantBatch)
+	}
+
+	if _, _, err := c.Protocols.ApplyBGPNeighbor(ctx, "192.0.2.3", BGPNeighbor{RemoteAS: "65003", Shutdown: true}); err != nil {
+		t.Fatalf("Protocols.ApplyBGPNeighbor returned error: %v", err)
+	}
+
+	batch = nil
+	if err := json.Unmarshal(configured, &batch); err != nil {
+		t.Fatalf("Error decoding Protocols.ApplyBGPNeighbor request: %v", err)
+	}
+	neighbor := base.Append("neighbor", "192.0.2.3")
+	wantBatch = []Request{
+		{OPMode: OPModeDelete, Path: neighbor},
+		{OPMode: OPModeSet, Path: neighbor.Append("remote-as", "65003")},
+		{OPMode: OPModeSet, Path: neighbor.Append("shutdown")},
+	}
+	if !reflect.DeepEqual(batch, wantBatch) {
+		t.Errorf("Protocols.ApplyBGPNeighbor sent %v, want %v", batch, wantBatch)
+	}
+
+	removals := []struct {
+		name string
+		call func() (*ConfigResponse, *Response, error)
+		want Path
+	}{
+		{"RemoveBGPNeighbor", func() (*ConfigResponse, *Response, error) { return c.Protocols.RemoveBGPNeighbor(ctx, "192.0.2.2") }, base.Append("neighbor", "192.0.2.2")},
+		{"RemoveBGP", func() (*ConfigResponse, *Response, error) { return c.Protocols.RemoveBGP(ctx) }, base},
+	}
+
+	for _, tt := range removals {
+		if _, _, err := tt.call(); err != nil {
+			t.Fatalf("Protocols.%s returned error: %v", tt.name, err)
+		}
+		var got Request
+		if err := json.Unmarshal(configured, &got); err != nil {
+			t.Fatalf("Error decoding Protocols.%s request: %v", tt.name, err)
+		}
+		if want := (Request{OPMode: OPModeDelete, Path: tt.want}); !reflect.DeepEqual(got, want) {
+			t.Errorf("Protocols.%s sent %v, want %v", tt.name, got, want)
+		}
+	}
+
+	if _, _, err := c.Protocols.ApplyBGPNeighbor(ctx, "", BGPNeighbor{}); err != ErrEmptyPath {
+		t.Errorf("Protocols.ApplyBGPNeighbor with no address returned %v, want %v", err, ErrEmptyPath)
+	}
+	if _, _, err := c.Protocols.RemoveBGPNeighbor(ctx, ""); err != ErrEmptyPath {
+		t.Errorf("Protocols.RemoveBGPNeighbor with no address returned %v, want %v", err, ErrEmptyPath)
+	}
+}
+
+// TestOSPF tests reading and writing the OSPF configuration.
+func TestOSPF(t *testing.T) {
+
+	t.Parallel()
+
+	var configured json.RawMessage
+	srv := newProtocolsServer(t, &configured)
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	ctx := context.TODO()
+	base := P("protocols", "ospf")
+
+	// The requests share one server, so they run in order.
+	ospf, _, err := c.Protocols.GetOSPF(ctx)
+	if err != nil {
+		t.Fatalf("Protocols.GetOSPF returned error: %v", err)
+	}
+
+	want := &OSPF{
+		Area: map[string]OSPFArea{
+			"0": {Network: []string{"10.0.0.0/24", "10.0.1.0/24"}},
+			"1": {Network: []string{"10.1.0.0/24"}},
+		},
+		Interface:  map[string]OSPFInterface{"eth0": {Cost: 10, Passive: true}},
+		Parameters: OSPFParameters{RouterID: "192.0.2.1"},
+	}
+	if !reflect.DeepEqual(ospf, want) {
+		t.Errorf("Protocols.GetOSPF is %+v, want %+v", ospf, want)
+	}
+
+	ospf.Area["1"] = OSPFArea{Network: []string{"10.1.0.0/24", "10.1.1.0/24"}}
+	if _, _, err := c.Protocols.ApplyOSPF(ctx, ospf); err != nil {
+		t.Fatalf("Protocols.ApplyOSPF returned error: %v", err)
+	}
+
+	var batch []Request
+	if err := json.Unmarshal(configured, &batch); err != nil {
+		t.Fatalf("Error decoding Protocols.ApplyOSPF request: %v", err)
+	}
+	wantBatch := []Request{
+		{OPMode: OPModeDelete, Path: base},
+		{OPMode: OPModeSet, Path: base.Append("area", "0", "network", "10.0.0.0/24")},
+		{OPMode: OPModeSet, Path: base.Append("area", "0", "network", "10.0.1.0/24")},
+		{OPMode: OPModeSet, Path: base.Append("area", "1", "network", "10.1.0.0/24")},
+		{OPMode: OPModeSet, Path: base.Append("area", "1", "network", "10.1.1.0/24")},
+		{OPMode: OPModeSet, Path: base.Append("interface", "eth0", "cost", "10")},
+		{OPMode: OPModeSet, Path: base.Append("interface", "eth0", "passive")},
+		{OPMode: OPModeSet, Path: base.Append("parameters", "router-id", "192.0.2.1")},
+	}
+	if !reflect.DeepEqual(batch, wantBatch) {
+		t.Errorf("Protocols.ApplyOSPF sent %v, want %v", batch, wantBatch)
+	}
+
+	if _, _, err := c.Protocols.RemoveOSPF(ctx); err != nil {
+		t.Fatalf("Protocols.RemoveOSPF returned error: %v", err)
+	}
+
+	var got Request
+	if err := json.Unmarshal(configured, &got); err != nil {
+		t.Fatalf("Error decoding Protocols.RemoveOSPF request: %v", err)
+	}
+	if want := (Request{OPMode: OPModeDelete, Path: base}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Protocols.RemoveOSPF sent %v, want %v", got, want)
+	}
+}
+
+// TestProtocolsApplyNil tests that applying a nil configuration deletes it.
+func TestProtocolsApplyNil(t *testing.T) {
+
+	t.Parallel()
+
+	var configured json.RawMessage
+	srv := newProtocolsServer(t, &configured)
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	ctx := context.TODO()
+
+	tests := []struct {
+		name string
+		call func() (*ConfigResponse, *Response, error)
+		want Path
+	}{
+		{"ApplyStatic", func() (*ConfigResponse, *Response, error) { return c.Protocols.ApplyStatic(ctx, nil) }, P("protocols", "static")},
+		{"ApplyBGP", func() (*ConfigResponse, *Response, error) { return c.Protocols.ApplyBGP(ctx, nil) }, P("protocols", "bgp")},
+		{"ApplyOSPF", func() (*ConfigResponse, *Response, error) { return c.Protocols.ApplyOSPF(ctx, nil) }, P("protocols", "ospf")},
+	}
+
+	// The requests share one server, so they run in order.
+	for _, tt := range tests {
+		if _, _, err := tt.call(); err != nil {
+			t.Fatalf("Protocols.%s returned error: %v", tt.name, err)
+		}
+		var got Request
+		if err := json.Unmarshal(configured, &got); err != nil {
+			t.Fatalf("Error decoding Protocols.%s request: %v", tt.name, err)
+		}
+		if want := (Request{OPMode: OPModeDelete, Path: tt.want}); !reflect.DeepEqual(got, want) {
+			t.Errorf("Protocols.%s sent %v, want %v", tt.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package vyos
+
+import (
+	"context"
+	"strings"
+)
+
+// Reconciler brings a configuration subtree to a desired state. It fetches
+// the live tree, computes the minimal set of set and delete operations, and
+// applies them as one batch followed by a commit.
+//
+// The desired state is authoritative for the whole subtree: live nodes that
+// are not present in it are deleted, and a nil or empty desired state
+// deletes the subtree.
+type Reconciler struct {
+	client *Client
+
+	Path    Path        // Root of the managed subtree, e.g. P("interfaces", "dummy").
+	Desired interface{} // Desired tree: a tagged struct or a map[string]interface{}.
+
+	// CommitConfirm, if greater than zero, commits with CommitConfirm using
+	// this many minutes instead of a plain Commit.
+	CommitConfirm int
+}
+
+// Plan is the list of operations needed to reach the desired state.
+type Plan struct {
+	Path     Path      // Root of the managed subtree.
+	Changes  Changes   // Differences between the live and desired trees.
+	Requests []Request // Operations, in the order they are applied.
+}
+
+// NewReconciler returns a Reconciler for the subtree at path.
+func NewReconciler(c *Client, path Path, desired interface{}) *Reconciler {
+	return &Reconciler{
+		client:  c,
+		Path:    path,
+		Desired: desired,
+	}
+}
+
+// Plan computes the operations needed to bring the live configuration to
+// the desired state. It does not change the router.
+func (r *Reconciler) Plan(ctx context.Context) (*Plan, *Response, error) {
+
+	if len(r.Path) == 0 {
+		return nil, nil, ErrEmptyPath
+	}
+
+	desired, err := Marshal(r.Desired)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exists, resp, err := r.client.Conf.ExistsPath(ctx, r.Path)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	var live interface{}
+	if found, _ := exists.Data.(bool); found {
+		out, resp, err := r.client.Conf.GetPath(ctx, r.Path, nil)
+		if err != nil {
+			return nil, resp, err
+		}
+		live = out.Data
+		// An existing node without children is returned as null.
+		if live == nil {
+			live = map[string]interface{}{}
+		}
+	}
+
+	plan := &Plan{Path: r.Path}
+	plan.Changes = diffValues(r.Path, live, desired)
+	plan.Requests = plan.Changes.Requests()
+
+	return plan, resp, nil
+}
+
+// Apply sends the operations of plan as a single batch and commits them. An
+// empty plan is a no-op and returns nil values.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) (*ConfigResponse, *Response, error) {
+
+	if plan == nil || plan.Empty() {
+		return nil, nil, nil
+	}
+
+	b := r.client.Conf.Batch().Add(plan.Requests...)
+	if _, resp, err := b.Do(ctx); err != nil {
+		return nil, resp, err
+	}
+
+	if r.CommitConfirm > 0 {
+		return r.client.Conf.CommitConfirm(ctx, r.CommitConfirm)
+	}
+
+	return r.client.Conf.Commit(ctx)
+}
+
+// Reconcile computes a plan and applies it. The plan is returned so callers
+// can log what was changed.
+func (r *Reconciler) Reconcile(ctx context.Context) (*Plan, *Response, error) {
+
+	plan, resp, err := r.Plan(ctx)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	_, resp, err = r.Apply(ctx, plan)
+	if err != nil {
+		return plan, resp, err
+	}
+
+	return plan, resp, nil
+}
+
+// Empty reports whether the plan has no operations.
+func (p *Plan) Empty() bool {
+	return len(p.Requests) == 0
+}
+
+// String returns the plan as CLI commands, one per line.
+func (p *Plan) String() string {
+
+	var b strings.Builder
+	for _, req := range p.Requests {
+		b.WriteString(string(req.OPMode))
+		b.WriteByte(' ')
+		b.WriteString(req.Path.String())
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
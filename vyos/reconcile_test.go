@@ -0,0 +1,55 @@
+package vyos
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestReconcilePlan tests the operations planned between two trees.
+func TestReconcilePlan(t *testing.T) {
+
+	t.Parallel()
+
+	live := map[string]interface{}{
+		"dum0": map[string]interface{}{
+			"address":     []interface{}{"10.0.0.1/32", "10.0.0.2/32"},
+			"description": "old",
+			"disable":     map[string]interface{}{},
+		},
+		"dum1": map[string]interface{}{
+			"address": "10.0.1.1/32",
+		},
+	}
+
+	desired := map[string]interface{}{
+		"dum0": map[string]interface{}{
+			"address":     "10.0.0.2/32",
+			"description": "new description",
+		},
+		"dum2": map[string]interface{}{},
+	}
+
+	base := P("interfaces", "dummy")
+	plan := &Plan{Path: base, Requests: diffValues(base, live, desired).Requests()}
+
+	want := "" +
+		"delete interfaces dummy dum0 address 10.0.0.1/32\n" +
+		"delete interfaces dummy dum0 description\n" +
+		"set interfaces dummy dum0 description 'new description'\n" +
+		"delete interfaces dummy dum0 disable\n" +
+		"delete interfaces dummy dum1\n" +
+		"set interfaces dummy dum2\n"
+
+	if got := plan.String(); got != want {
+		t.Errorf("Plan is\n%v\nwant\n%v", got, want)
+	}
+
+	if reqs := diffValues(base, live, live).Requests(); len(reqs) != 0 {
+		t.Errorf("Diff of identical trees is %v, want no operations", reqs)
+	}
+
+	want2 := []Request{{OPMode: OPModeDelete, Path: base}}
+	if reqs := diffValues(base, live, nil).Requests(); !reflect.DeepEqual(reqs, want2) {
+		t.Errorf("Diff to nil is %v, want %v", reqs, want2)
+	}
+}
@@ -0,0 +1,249 @@
+package vyos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Default values used by RetryPolicy when fields are left unset.
+const (
+	defaultRetryMinBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 30 * time.Second
+)
+
+// idempotentEndpoints are the endpoints that are retried without opting in.
+// Retrying them can not change the router configuration.
+var idempotentEndpoints = map[string]bool{
+	"/retrieve": true,
+	"/show":     true,
+}
+
+// RetryPolicy configures how the client retries transient API failures.
+//
+// Requests to /retrieve and /show are retried automatically. Requests to
+// other endpoints, such as /configure, change the router state and are only
+// retried when RetryNonIdempotent is set.
+type RetryPolicy struct {
+	MaxAttempts int           // Total number of attempts, including the first. Values below 2 disable retries.
+	MinBackoff  time.Duration // Delay before the first retry. Defaults to 500ms.
+	MaxBackoff  time.Duration // Upper bound for any delay, including Retry-After. Defaults to 30s.
+
+	// Jitter randomizes each delay between half and the full backoff, so
+	// clients retrying at the same time spread out.
+	Jitter bool
+
+	// RetryableStatus lists the HTTP status codes that are retried. Defaults
+	// to 429, 502, 503 and 504 when nil.
+	RetryableStatus []int
+
+	// RetryNonIdempotent enables retries for endpoints other than /retrieve
+	// and /show, e.g. /configure.
+	RetryNonIdempotent bool
+
+	// Retryable, if set, overrides the default classification of retryable
+	// failures. resp is nil if the request failed without a response.
+	Retryable func(resp *http.Response, err error) bool
+}
+
+// DefaultRetryPolicy returns a retry policy with three attempts, jittered
+// exponential backoff and the default retryable status codes.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  defaultRetryMinBackoff,
+		MaxBackoff:  defaultRetryMaxBackoff,
+		Jitter:      true,
+	}
+}
+
+// WithRetry sets the retry policy for the VyOS API client.
+func (c *Client) WithRetry(policy RetryPolicy) *Client {
+	newClient := c.copy()
+	defer newClient.init()
+
+	// Set the retry policy for the API client.
+	newClient.retry = &policy
+
+	return newClient
+}
+
+// allows reports whether req may be retried under the policy.
+func (p *RetryPolicy) allows(req *http.Request) bool {
+
+	if p == nil || p.MaxAttempts < 2 {
+		return false
+	}
+
+	// The body must be re-created for every attempt.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	return p.RetryNonIdempotent || idempotentEndpoints[req.URL.Path]
+}
+
+// shouldRetry reports whether the outcome of an attempt is a transient
+// failure. ctx is the context of the request.
+func (p *RetryPolicy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+
+	// Never retry once the caller has given up.
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if p.Retryable != nil {
+		return p.Retryable(resp, err)
+	}
+
+	// A router busy committing reports the configuration as locked.
+	if errors.Is(err, ErrConfigLocked) {
+		return true
+	}
+
+	// Errors without a response are retried only if they are transient, e.g.
+	// connection refused while the API restarts. TLS verification and
+	// invalid URL errors fail the same way every time.
+	if resp == nil {
+		return transientError(err)
+	}
+
+	codes := p.RetryableStatus
+	if codes == nil {
+		codes = []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transientError reports whether err is a timeout or connection error that
+// may succeed when retried.
+func transientError(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+
+	// The connection was refused, reset or closed, e.g. during a restart.
+	for _, target := range []error{
+		syscall.ECONNREFUSED,
+		syscall.ECONNRESET,
+		syscall.ECONNABORTED,
+		syscall.EHOSTUNREACH,
+		syscall.ENETUNREACH,
+		io.EOF,
+		io.ErrUnexpectedEOF,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay before the given retry, starting at 1. A
+// Retry-After header on resp takes precedence over the computed backoff.
+func (p *RetryPolicy) backoff(retry int, resp *http.Response) time.Duration {
+
+	min, max := p.MinBackoff, p.MaxBackoff
+	if min <= 0 {
+		min = defaultRetryMinBackoff
+	}
+	if max <= 0 {
+		max = defaultRetryMaxBackoff
+	}
+
+	if d, ok := retryAfter(resp); ok {
+		return clampDuration(d, 0, max)
+	}
+
+	// Double the delay for every retry, stopping at the maximum.
+	d := min
+	for i := 1; i < retry && d < max; i++ {
+		d *= 2
+	}
+	d = clampDuration(d, min, max)
+
+	if p.Jitter && d > 1 {
+		d = d/2 + rand.N(d/2)
+	}
+
+	return d
+}
+
+// retryAfter parses the Retry-After header, either in seconds or as an HTTP
+// date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+
+	if resp == nil {
+		return 0, false
+	}
+
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+
+	return 0, false
+}
+
+// clampDuration limits d to the range [min, max].
+func clampDuration(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package vyos
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrRuleExists        = errors.New("rule number is already in use")
+	ErrRuleNotFound      = errors.New("rule number does not exist")
+	ErrInvalidRuleNumber = errors.New("rule number must be greater than zero")
+	ErrNoFreeRule        = errors.New("no free rule number available")
+)
+
+// ruleNumbers returns the sorted rule numbers of a `rule` tag node.
+func ruleNumbers(rules map[string]interface{}) []int {
+
+	numbers := make([]int, 0, len(rules))
+	for k := range rules {
+		if n, err := strconv.Atoi(k); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+
+	return numbers
+}
+
+// ruleNode returns the `rule` tag node of a rule set node.
+func ruleNode(set map[string]interface{}) map[string]interface{} {
+
+	rules, _ := set["rule"].(map[string]interface{})
+	if rules == nil {
+		rules = map[string]interface{}{}
+	}
+
+	return rules
+}
+
+// nextFreeRule returns the first unused rule number at or after start,
+// stepping by step, that does not exceed max.
+func nextFreeRule(rules map[string]interface{}, start, step, max int) (int, error) {
+
+	if start <= 0 || step <= 0 {
+		return 0, ErrInvalidRuleNumber
+	}
+
+	for n := start; n <= max; n += step {
+		if _, used := rules[strconv.Itoa(n)]; !used {
+			return n, nil
+		}
+	}
+
+	return 0, ErrNoFreeRule
+}
+
+// insertRule adds the operations that insert rule at number under base to
+// the batch. Rules occupying number and the consecutive numbers after it are
+// shifted up by one to make room. If a shifted rule would exceed max, nothing
+// is added and ErrNoFreeRule is returned.
+func (b *ConfigBatch) insertRule(base Path, rules map[string]interface{}, number, max int, rule interface{}) error {
+
+	// Find the block of consecutive rules that has to move.
+	end := number
+	for {
+		if _, used := rules[strconv.Itoa(end)]; !used {
+			break
+		}
+		end++
+	}
+
+	if end > max {
+		return ErrNoFreeRule
+	}
+
+	// Move from the highest number down so nothing is overwritten.
+	for n := end - 1; n >= number; n-- {
+		b.moveRule(base, rules[strconv.Itoa(n)], n, n+1)
+	}
+
+	b.setRule(base, number, rule)
+
+	return nil
+}
+
+// moveRule adds the operations that move a rule from one number to another.
+func (b *ConfigBatch) moveRule(base Path, rule interface{}, from, to int) {
+	b.DeletePath(base.Append(strconv.Itoa(from)))
+	b.setRule(base, to, rule)
+}
+
+// setRule adds the set operations for a rule tree at number under base.
+func (b *ConfigBatch) setRule(base Path, number int, rule interface{}) {
+
+	path := base.Append(strconv.Itoa(number))
+
+	paths := SetPaths(path, rule)
+	if len(paths) == 0 {
+		paths = []Path{path}
+	}
+
+	for _, p := range paths {
+		b.SetPath(p)
+	}
+}
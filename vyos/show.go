@@ -2,7 +2,6 @@ package vyos
 
 import (
 	"context"
-	"strings"
 )
 
 // Response represents a response from the VyOS API.
@@ -16,21 +15,31 @@ type ShowResponse struct {
 // Do sends a request to the VyOS API and returns the response.
 func (s *ShowService) Do(ctx context.Context, path string) (*ShowResponse, *Response, error) {
 
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.DoPath(ctx, p)
+}
+
+// DoPath is like Do but takes a structured Path.
+func (s *ShowService) DoPath(ctx context.Context, path Path) (*ShowResponse, *Response, error) {
+
 	u := "/show"
-	p := strings.Split(path, " ")
 
-	if p == nil {
+	if len(path) == 0 {
 		return nil, nil, ErrEmptyPath
 	}
 
 	// Create a new request.
 	request := Request{
 		OPMode: OPModeShow,
-		Path:   p,
+		Path:   path,
 	}
 
 	// Create the HTTP request.
-	req, err := s.client.NewRequest(u, &request)
+	req, err := s.client.NewRequestWithContext(ctx, u, &request)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,392 @@
+package vyos
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrNotNode = errors.New("path passes through a leaf value")
+	ErrNotTree = errors.New("response data is not a configuration tree")
+
+	// SkipNode can be returned by a WalkFunc to skip the children of a node.
+	SkipNode = errors.New("skip this node")
+)
+
+// ConfigTree is a configuration tree in the shape returned by the /retrieve
+// endpoint: nodes are maps, leaf values are strings, multi-value leaves are
+// lists of strings and valueless leaves are empty maps.
+//
+// Like a map, a ConfigTree must be non-nil before it is modified.
+type ConfigTree map[string]interface{}
+
+// WalkFunc is called by ConfigTree.Walk for every node and leaf. value is a
+// map[string]interface{} for nodes, a string for single-value leaves and a
+// []interface{} for multi-value leaves.
+type WalkFunc func(path Path, value interface{}) error
+
+// TreeOf converts v into a ConfigTree. v can be a ConfigTree, a
+// map[string]interface{} or a tagged struct, as understood by Marshal.
+func TreeOf(v interface{}) (ConfigTree, error) {
+
+	switch t := v.(type) {
+	case ConfigTree:
+		return t, nil
+	case map[string]interface{}:
+		return ConfigTree(t), nil
+	}
+
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil {
+		return ConfigTree{}, nil
+	}
+
+	node, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%T does not encode to a configuration node", v)
+	}
+
+	return ConfigTree(node), nil
+}
+
+// Tree returns a copy of the response data as a ConfigTree. The response
+// must come from a showConfig request, such as ConfigService.Get without
+// MultiValue.
+func (r *ConfigResponse) Tree() (ConfigTree, error) {
+
+	if r == nil || r.RawResponse == nil || r.Data == nil {
+		return ConfigTree{}, nil
+	}
+
+	node, ok := r.Data.(map[string]interface{})
+	if !ok {
+		return nil, ErrNotTree
+	}
+
+	return ConfigTree(node).Copy(), nil
+}
+
+// Get returns the node or leaf value at path. As with the VyOS CLI, the last
+// element may also be one of the values of a leaf.
+func (t ConfigTree) Get(path Path) (interface{}, bool) {
+
+	var cur interface{} = map[string]interface{}(t)
+
+	for i, elem := range path {
+
+		node, ok := asNode(cur)
+		if !ok {
+			// Only the last element may name a leaf value.
+			if i == len(path)-1 && slices.Contains(leafValues(cur), elem) {
+				return elem, true
+			}
+			return nil, false
+		}
+
+		cur, ok = node[elem]
+		if !ok {
+			return nil, false
+		}
+
+		if cur == nil {
+			cur = map[string]interface{}{}
+		}
+	}
+
+	return cur, true
+}
+
+// Exists reports whether path exists in the tree.
+func (t ConfigTree) Exists(path Path) bool {
+	_, ok := t.Get(path)
+	return ok
+}
+
+// Values returns the values of the leaf at path, which is a single value for
+// single-value leaves. It returns nil if path does not lead to a leaf.
+func (t ConfigTree) Values(path Path) []string {
+
+	v, ok := t.Get(path)
+	if !ok {
+		return nil
+	}
+
+	if _, isNode := asNode(v); isNode {
+		return nil
+	}
+
+	return leafValues(v)
+}
+
+// Set sets the leaf at path, creating intermediate nodes as needed.
+// value can be:
+//   - nil, to create an empty node or valueless leaf;
+//   - a string, to set a single value, replacing any existing values;
+//   - a []string, to set all values of a multi-value leaf;
+//   - a node (ConfigTree or map[string]interface{}), which is copied in.
+func (t ConfigTree) Set(path Path, value interface{}) error {
+
+	if len(path) == 0 {
+		return ErrEmptyPath
+	}
+
+	parent, err := t.makeNode(path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+
+	name := path[len(path)-1]
+
+	switch v := value.(type) {
+	case nil:
+		if _, isNode := asNode(parent[name]); !isNode {
+			parent[name] = map[string]interface{}{}
+		}
+	case string:
+		parent[name] = v
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+		parent[name] = values
+	case ConfigTree:
+		parent[name] = copyValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		parent[name] = copyValue(v)
+	default:
+		return fmt.Errorf("cannot set %T in a configuration tree", value)
+	}
+
+	return nil
+}
+
+// AddValue adds value to the multi-value leaf at path, creating it if needed.
+// Adding a value that is already present does nothing.
+func (t ConfigTree) AddValue(path Path, value string) error {
+
+	if len(path) == 0 {
+		return ErrEmptyPath
+	}
+
+	parent, err := t.makeNode(path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+
+	name := path[len(path)-1]
+	existing, ok := parent[name]
+
+	switch {
+	case !ok:
+		parent[name] = value
+	case isList(existing) || !isNodeValue(existing):
+		values := leafValues(existing)
+		if slices.Contains(values, value) {
+			return nil
+		}
+		list := make([]interface{}, 0, len(values)+1)
+		for _, s := range values {
+			list = append(list, s)
+		}
+		parent[name] = append(list, value)
+	default:
+		return ErrNotNode
+	}
+
+	return nil
+}
+
+// Delete removes the node or leaf at path and reports whether anything was
+// removed. As with the VyOS CLI, the last element may be one of the values
+// of a leaf to remove only that value.
+func (t ConfigTree) Delete(path Path) bool {
+
+	if len(path) == 0 {
+		return false
+	}
+
+	var cur interface{} = map[string]interface{}(t)
+	var parent map[string]interface{}
+	var parentKey string
+
+	for i, elem := range path {
+
+		node, ok := asNode(cur)
+		if !ok {
+			// Deleting a single value of the leaf cur.
+			if i != len(path)-1 {
+				return false
+			}
+
+			values := leafValues(cur)
+			idx := slices.Index(values, elem)
+			if idx < 0 {
+				return false
+			}
+
+			values = slices.Delete(values, idx, idx+1)
+			switch len(values) {
+			case 0:
+				delete(parent, parentKey)
+			case 1:
+				parent[parentKey] = values[0]
+			default:
+				list := make([]interface{}, len(values))
+				for j, s := range values {
+					list[j] = s
+				}
+				parent[parentKey] = list
+			}
+			return true
+		}
+
+		next, ok := node[elem]
+		if !ok {
+			return false
+		}
+
+		if i == len(path)-1 {
+			delete(node, elem)
+			return true
+		}
+
+		parent, parentKey, cur = node, elem, next
+	}
+
+	return false
+}
+
+// Walk calls fn for every node and leaf in the tree, depth first with
+// children in sorted order. If fn returns SkipNode for a node its children
+// are skipped; any other error stops the walk and is returned.
+func (t ConfigTree) Walk(fn WalkFunc) error {
+
+	err := walk(nil, map[string]interface{}(t), fn)
+	if err == SkipNode {
+		return nil
+	}
+
+	return err
+}
+
+// Copy returns a deep copy of the tree.
+func (t ConfigTree) Copy() ConfigTree {
+
+	if t == nil {
+		return nil
+	}
+
+	return ConfigTree(copyValue(map[string]interface{}(t)).(map[string]interface{}))
+}
+
+// makeNode returns the node at path, creating missing nodes.
+func (t ConfigTree) makeNode(path Path) (map[string]interface{}, error) {
+
+	node := map[string]interface{}(t)
+
+	for _, elem := range path {
+
+		child, ok := node[elem]
+		if !ok || child == nil {
+			next := map[string]interface{}{}
+			node[elem] = next
+			node = next
+			continue
+		}
+
+		next, isNode := asNode(child)
+		if !isNode {
+			return nil, ErrNotNode
+		}
+
+		// Store named tree types as plain nodes so they can be modified.
+		node[elem] = next
+		node = next
+	}
+
+	return node, nil
+}
+
+// walk calls fn for the children of node.
+func walk(path Path, node map[string]interface{}, fn WalkFunc) error {
+
+	for _, k := range sortedKeys(node) {
+
+		p := path.Append(k)
+		child := node[k]
+		if child == nil {
+			child = map[string]interface{}{}
+		}
+
+		childNode, isNode := asNode(child)
+		if isNode {
+			child = childNode
+		}
+
+		err := fn(p, child)
+		if err == SkipNode {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if isNode {
+			if err := walk(p, childNode, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// asNode returns v as a node if it is one.
+func asNode(v interface{}) (map[string]interface{}, bool) {
+
+	switch n := v.(type) {
+	case map[string]interface{}:
+		return n, true
+	case ConfigTree:
+		return map[string]interface{}(n), true
+	}
+
+	return nil, false
+}
+
+// isNodeValue reports whether v is a node.
+func isNodeValue(v interface{}) bool {
+	_, ok := asNode(v)
+	return ok
+}
+
+// copyValue returns a deep copy of a tree value.
+func copyValue(v interface{}) interface{} {
+
+	switch n := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(n))
+		for k, child := range n {
+			c[k] = copyValue(child)
+		}
+		return c
+	case ConfigTree:
+		return copyValue(map[string]interface{}(n))
+	case []interface{}:
+		return append([]interface{}(nil), n...)
+	case []string:
+		c := make([]interface{}, len(n))
+		for i, s := range n {
+			c[i] = s
+		}
+		return c
+	}
+
+	return v
+}
@@ -0,0 +1,92 @@
+package vyos
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestConfigTree tests querying and modifying a configuration tree.
+func TestConfigTree(t *testing.T) {
+
+	t.Parallel()
+
+	resp := &ConfigResponse{RawResponse: &RawResponse{Success: true, Data: map[string]interface{}{
+		"interfaces": map[string]interface{}{
+			"ethernet": map[string]interface{}{
+				"eth0": map[string]interface{}{
+					"address": []interface{}{"192.0.2.1/24", "2001:db8::1/64"},
+					"hw-id":   "00:00:5e:00:53:01",
+				},
+			},
+		},
+	}}}
+
+	tree, err := resp.Tree()
+	if err != nil {
+		t.Fatalf("ConfigResponse.Tree returned error: %v", err)
+	}
+
+	eth0 := P("interfaces", "ethernet", "eth0")
+
+	if got, want := tree.Values(eth0.Append("address")), []string{"192.0.2.1/24", "2001:db8::1/64"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values is %v, want %v", got, want)
+	}
+
+	if !tree.Exists(eth0.Append("address", "192.0.2.1/24")) {
+		t.Error("Exists for a leaf value is false, want true")
+	}
+
+	if tree.Exists(eth0.Append("hw-id", "x", "y")) {
+		t.Error("Exists below a leaf value is true, want false")
+	}
+
+	orig := tree.Copy()
+
+	if err := tree.Set(eth0.Append("description"), "Uplink to ISP"); err != nil {
+		t.Errorf("Set returned error: %v", err)
+	}
+
+	if err := tree.Set(eth0.Append("disable"), nil); err != nil {
+		t.Errorf("Set returned error: %v", err)
+	}
+
+	if err := tree.AddValue(eth0.Append("address"), "198.51.100.1/24"); err != nil {
+		t.Errorf("AddValue returned error: %v", err)
+	}
+
+	if !tree.Delete(eth0.Append("address", "192.0.2.1/24")) {
+		t.Error("Delete of a leaf value returned false, want true")
+	}
+
+	if err := tree.Set(eth0.Append("hw-id", "x"), "y"); err != ErrNotNode {
+		t.Errorf("Set below a leaf returned %v, want %v", err, ErrNotNode)
+	}
+
+	want := []Change{
+		{Kind: ChangeRemoved, Path: eth0.Append("address"), Old: "192.0.2.1/24"},
+		{Kind: ChangeAdded, Path: eth0.Append("address"), New: "198.51.100.1/24"},
+		{Kind: ChangeAdded, Path: eth0.Append("description"), New: "Uplink to ISP"},
+		{Kind: ChangeAdded, Path: eth0.Append("disable"), New: map[string]interface{}{}},
+	}
+
+	if got := Diff(orig, tree); !reflect.DeepEqual(got, Changes(want)) {
+		t.Errorf("Diff after changes is %v, want %v", got, want)
+	}
+
+	if !reflect.DeepEqual(resp.Data, map[string]interface{}(orig)) {
+		t.Error("Modifying the tree changed the response data")
+	}
+
+	var walked []string
+	tree.Walk(func(path Path, value interface{}) error {
+		walked = append(walked, path.String())
+		if len(path) == 3 {
+			return SkipNode
+		}
+		return nil
+	})
+
+	if want := []string{"interfaces", "interfaces ethernet", "interfaces ethernet eth0"}; !reflect.DeepEqual(walked, want) {
+		t.Errorf("Walk visited %v, want %v", walked, want)
+	}
+}
@@ -0,0 +1,301 @@
+package vyos
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrImageNotInstalled = errors.New("new image is not installed")
+	ErrUpgradeVerify     = errors.New("router is not running the new image")
+	ErrNoRunningImage    = errors.New("no image is marked as running")
+)
+
+// UpgradeStep identifies a step of the upgrade workflow.
+type UpgradeStep string
+
+// UpgradeStep constants, in the order they are run.
+const (
+	UpgradeStepVerify     UpgradeStep = "verify"      // Read the running version and images.
+	UpgradeStepAdd        UpgradeStep = "add"         // Download and install the new image.
+	UpgradeStepSetDefault UpgradeStep = "set-default" // Boot the new image by default.
+	UpgradeStepSave       UpgradeStep = "save"        // Save the running configuration.
+	UpgradeStepReboot     UpgradeStep = "reboot"      // Reboot into the new image.
+	UpgradeStepWait       UpgradeStep = "wait"        // Wait for the API to come back.
+	UpgradeStepCheck      UpgradeStep = "check"       // Verify the new image is running.
+	UpgradeStepRollback   UpgradeStep = "rollback"    // Return to the previous image.
+)
+
+// UpgradeOptions configures Client.Upgrade.
+type UpgradeOptions struct {
+	// ExpectedVersion is the version the new image reports. If the router
+	// already runs it nothing is done; after the reboot it must match.
+	ExpectedVersion string
+
+	// Rollback returns to the previous image if a step after setting the
+	// new image as default fails.
+	Rollback bool
+
+	PollInterval  time.Duration // Interval between checks while waiting for the reboot. Defaults to 10s.
+	RebootTimeout time.Duration // Maximum time to wait for the reboot. Defaults to 15m.
+
+	// Progress, if set, is called when a step starts.
+	Progress func(step UpgradeStep)
+}
+
+// UpgradeResult describes the outcome of Client.Upgrade.
+type UpgradeResult struct {
+	PreviousVersion string // Version running before the upgrade.
+	PreviousImage   string // Image running before the upgrade.
+	Version         string // Version running after the upgrade.
+	Image           string // Image added by the upgrade.
+	Skipped         bool   // The expected version was already running.
+	RolledBack      bool   // The router was returned to the previous image.
+}
+
+// Upgrade upgrades the router to the image at url. It verifies the running
+// version, adds the image, sets it as default, saves the configuration,
+// reboots and waits for the API to come back, then verifies the new image is
+// running. With opts.Rollback set, a failure after the new image has been
+// set as default makes the previous image the default again, rebooting into
+// it if the router was already rebooted.
+func (c *Client) Upgrade(ctx context.Context, url string, opts *UpgradeOptions) (*UpgradeResult, error) {
+
+	if opts == nil {
+		opts = &UpgradeOptions{}
+	}
+
+	u := &upgrade{client: c, opts: opts, result: &UpgradeResult{}}
+
+	return u.result, u.run(ctx, url)
+}
+
+// upgrade holds the state of a running Client.Upgrade.
+type upgrade struct {
+	client *Client
+	opts   *UpgradeOptions
+	result *UpgradeResult
+}
+
+func (u *upgrade) run(ctx context.Context, url string) error {
+
+	c := u.client
+
+	// Verify the running version and remember the images installed.
+	u.step(UpgradeStepVerify)
+	version, err := showVersion(ctx, c)
+	if err != nil {
+		return upgradeError(UpgradeStepVerify, err)
+	}
+	u.result.PreviousVersion = version
+
+	if u.opts.ExpectedVersion != "" && version == u.opts.ExpectedVersion {
+		u.result.Version = version
+		u.result.Skipped = true
+		return nil
+	}
+
+	before, _, err := c.Image.List(ctx)
+	if err != nil {
+		return upgradeError(UpgradeStepVerify, err)
+	}
+	u.result.PreviousImage = runningImage(before)
+	if u.result.PreviousImage == "" {
+		// Without it the upgrade could neither be checked nor rolled back.
+		return upgradeError(UpgradeStepVerify, ErrNoRunningImage)
+	}
+
+	// Add the image and find its name.
+	u.step(UpgradeStepAdd)
+	if _, _, err := c.Image.Add(ctx, url); err != nil {
+		return upgradeError(UpgradeStepAdd, err)
+	}
+
+	after, _, err := c.Image.List(ctx)
+	if err != nil {
+		return upgradeError(UpgradeStepAdd, err)
+	}
+	u.result.Image = newImage(before, after)
+	if u.result.Image == "" {
+		return upgradeError(UpgradeStepAdd, ErrImageNotInstalled)
+	}
+
+	u.step(UpgradeStepSetDefault)
+	if _, _, err := c.Image.SetDefault(ctx, u.result.Image); err != nil {
+		return upgradeError(UpgradeStepSetDefault, err)
+	}
+
+	// From here on the previous image can be restored.
+	u.step(UpgradeStepSave)
+	if _, _, err := c.Conf.Save(ctx, ""); err != nil {
+		return u.rollback(ctx, UpgradeStepSave, err, false)
+	}
+
+	u.step(UpgradeStepReboot)
+	if _, _, err := c.Power.Reboot(ctx); err != nil {
+		return u.rollback(ctx, UpgradeStepReboot, err, false)
+	}
+
+	u.step(UpgradeStepWait)
+	if err := u.waitForReboot(ctx); err != nil {
+		return u.rollback(ctx, UpgradeStepWait, err, true)
+	}
+
+	// Verify the router came back on the new image.
+	u.step(UpgradeStepCheck)
+	if err := u.check(ctx); err != nil {
+		return u.rollback(ctx, UpgradeStepCheck, err, true)
+	}
+
+	return nil
+}
+
+// check verifies the new image and version are running.
+func (u *upgrade) check(ctx context.Context) error {
+
+	c := u.client
+
+	version, err := showVersion(ctx, c)
+	if err != nil {
+		return err
+	}
+	u.result.Version = version
+
+	images, _, err := c.Image.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	if running := runningImage(images); running != u.result.Image {
+		return fmt.Errorf("%w: running %q", ErrUpgradeVerify, running)
+	}
+
+	if u.opts.ExpectedVersion != "" && version != u.opts.ExpectedVersion {
+		return fmt.Errorf("%w: version is %s, want %s", ErrUpgradeVerify, version, u.opts.ExpectedVersion)
+	}
+
+	return nil
+}
+
+// rollback restores the previous image as default if enabled, rebooting
+// into it if the router was rebooted. It returns the error of the failed
+// step, joined with any rollback error.
+func (u *upgrade) rollback(ctx context.Context, step UpgradeStep, err error, rebooted bool) error {
+
+	err = upgradeError(step, err)
+
+	if !u.opts.Rollback || u.result.PreviousImage == "" {
+		return err
+	}
+
+	c := u.client
+
+	u.step(UpgradeStepRollback)
+	if _, _, errRollback := c.Image.SetDefault(ctx, u.result.PreviousImage); errRollback != nil {
+		return errors.Join(err, upgradeError(UpgradeStepRollback, errRollback))
+	}
+
+	if rebooted {
+		if _, _, errRollback := c.Power.Reboot(ctx); errRollback != nil {
+			return errors.Join(err, upgradeError(UpgradeStepRollback, errRollback))
+		}
+		if errRollback := u.waitForReboot(ctx); errRollback != nil {
+			return errors.Join(err, upgradeError(UpgradeStepRollback, errRollback))
+		}
+	}
+
+	u.result.RolledBack = true
+
+	return err
+}
+
+// waitForReboot waits for the router to reboot, bounded by the reboot
+// timeout.
+func (u *upgrade) waitForReboot(ctx context.Context) error {
+
+	timeout := u.opts.RebootTimeout
+	if timeout <= 0 {
+		timeout = 15 * time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return u.client.Power.WaitForReboot(ctx, u.opts.PollInterval)
+}
+
+// step reports the start of a step.
+func (u *upgrade) step(step UpgradeStep) {
+	if u.opts.Progress != nil {
+		u.opts.Progress(step)
+	}
+}
+
+// runningImage returns the name of the running image, or "" if no image is
+// marked as running.
+func runningImage(images []Image) string {
+
+	for _, img := range images {
+		if img.Running {
+			return img.Name
+		}
+	}
+
+	return ""
+}
+
+// upgradeError annotates err with the failed step.
+func upgradeError(step UpgradeStep, err error) error {
+	return fmt.Errorf("upgrade %s: %w", step, err)
+}
+
+// newImage returns the name of the first image in after that is not in
+// before.
+func newImage(before, after []Image) string {
+
+	installed := make(map[string]bool, len(before))
+	for _, img := range before {
+		installed[img.Name] = true
+	}
+
+	for _, img := range after {
+		if !installed[img.Name] {
+			return img.Name
+		}
+	}
+
+	return ""
+}
+
+// showVersion returns the running version from `show version`.
+func showVersion(ctx context.Context, c *Client) (string, error) {
+
+	out, _, err := c.Show.DoPath(ctx, P("version"))
+	if err != nil {
+		return "", err
+	}
+
+	text, _ := out.Data.(string)
+
+	return parseVersion(text), nil
+}
+
+// parseVersion returns the version from the output of `show version`, e.g.
+// `1.4.0` for `Version:          VyOS 1.4.0`.
+func parseVersion(text string) string {
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(key) != "Version" {
+			continue
+		}
+		return strings.TrimPrefix(strings.TrimSpace(value), "VyOS ")
+	}
+
+	return ""
+}
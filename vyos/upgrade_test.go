@@ -0,0 +1,212 @@
+package vyos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upgradeRouter simulates the endpoints used by Client.Upgrade.
+type upgradeRouter struct {
+	images   []string
+	def      string
+	running  string
+	broken   string // Image that fails to boot.
+	unmarked int    // Number of image lists, counted from the first reboot, that mark no image as running.
+	rebooted bool   // Set by the first reboot.
+	down     int    // Number of requests to fail while rebooting.
+	requests []string
+}
+
+func (u *upgradeRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		OPMode string   `json:"op"`
+		Path   []string `json:"path"`
+		Name   string   `json:"name"`
+	}
+	json.Unmarshal([]byte(r.FormValue("data")), &req)
+	u.requests = append(u.requests, r.URL.Path+" "+req.OPMode)
+
+	if u.down > 0 {
+		u.down--
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	var data string
+	switch r.URL.Path {
+	case "/show":
+		if strings.Join(req.Path, " ") == "version" {
+			data = "Version:          VyOS " + u.running + "\n"
+			break
+		}
+		var b strings.Builder
+		b.WriteString("The system currently has the following image(s) installed:\n\n")
+		for i, img := range u.images {
+			fmt.Fprintf(&b, "   %d: %s", i+1, img)
+			if img == u.def {
+				b.WriteString(" (default boot)")
+			}
+			if img == u.running && !u.hideRunning() {
+				b.WriteString(" (running image)")
+			}
+			b.WriteString("\n")
+		}
+		data = b.String()
+
+	case "/image":
+		switch req.OPMode {
+		case "add":
+			u.images = append([]string{"1.4.0"}, u.images...)
+		case "set_default":
+			u.def = req.Name
+		}
+
+	case "/reboot":
+		u.rebooted = true
+		u.down = 1
+		u.running = u.def
+		if u.running == u.broken {
+			u.running = u.images[len(u.images)-1]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "error": nil, "data": data})
+}
+
+// hideRunning reports whether the current image list marks no image as
+// running.
+func (u *upgradeRouter) hideRunning() bool {
+
+	if !u.rebooted || u.unmarked == 0 {
+		return false
+	}
+	u.unmarked--
+
+	return true
+}
+
+// TestUpgrade tests the upgrade workflow and its rollback.
+func TestUpgrade(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		broken         string
+		unmarked       int
+		wantErr        error
+		wantVersion    string
+		wantRolledBack bool
+	}{
+		{
+			name:        "success",
+			wantVersion: "1.4.0",
+		},
+		{
+			name:           "rollback",
+			broken:         "1.4.0",
+			wantErr:        ErrUpgradeVerify,
+			wantVersion:    "1.3.2",
+			wantRolledBack: true,
+		},
+		{
+			// The new image boots with the expected version, but the
+			// image list does not show it running.
+			name:           "not running",
+			unmarked:       1,
+			wantErr:        ErrUpgradeVerify,
+			wantVersion:    "1.3.2",
+			wantRolledBack: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// The router is served in the calling goroutine, so the 1ms
+			// polls of WaitForReboot see it go down.
+			router := &upgradeRouter{images: []string{"1.3.2"}, def: "1.3.2", running: "1.3.2", broken: tt.broken, unmarked: tt.unmarked}
+			c := NewClient(&http.Client{Transport: handlerTransport{router}}).WithURL("http://router").WithToken("test")
+			result, err := c.Upgrade(context.TODO(), "https://example.com/vyos-1.4.0.iso", &UpgradeOptions{
+				ExpectedVersion: "1.4.0",
+				Rollback:        true,
+				PollInterval:    time.Millisecond,
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Upgrade returned %v, want %v", err, tt.wantErr)
+			}
+
+			if got, want := result.PreviousImage, "1.3.2"; got != want {
+				t.Errorf("PreviousImage is %v, want %v", got, want)
+			}
+
+			if got, want := result.Image, "1.4.0"; got != want {
+				t.Errorf("Image is %v, want %v", got, want)
+			}
+
+			if got, want := router.running, tt.wantVersion; got != want {
+				t.Errorf("running image is %v, want %v", got, want)
+			}
+
+			if got, want := result.RolledBack, tt.wantRolledBack; got != want {
+				t.Errorf("RolledBack is %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// TestUpgradeSkipped tests that nothing is done if the expected version runs.
+func TestUpgradeSkipped(t *testing.T) {
+
+	t.Parallel()
+
+	router := &upgradeRouter{images: []string{"1.4.0"}, def: "1.4.0", running: "1.4.0"}
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+	result, err := c.Upgrade(context.TODO(), "https://example.com/vyos-1.4.0.iso", &UpgradeOptions{ExpectedVersion: "1.4.0"})
+	if err != nil {
+		t.Fatalf("Upgrade returned error: %v", err)
+	}
+
+	if !result.Skipped {
+		t.Error("Upgrade Skipped is false, want true")
+	}
+
+	if got, want := len(router.requests), 1; got != want {
+		t.Errorf("requests is %v, want %v", got, want)
+	}
+}
+
+// TestUpgradeNoRunningImage tests that an upgrade is refused if the running
+// image is unknown, as it could not be rolled back.
+func TestUpgradeNoRunningImage(t *testing.T) {
+
+	t.Parallel()
+
+	router := &upgradeRouter{images: []string{"1.3.2"}, def: "1.3.2", running: "1.3.2", rebooted: true, unmarked: 1}
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+
+	_, err := c.Upgrade(context.TODO(), "https://example.com/vyos-1.4.0.iso", &UpgradeOptions{Rollback: true})
+	if !errors.Is(err, ErrNoRunningImage) {
+		t.Fatalf("Upgrade returned %v, want %v", err, ErrNoRunningImage)
+	}
+
+	if got, want := len(router.images), 1; got != want {
+		t.Errorf("images is %v, want %v", got, want)
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
-	"fmt"
-	"mime/multipart"
+	"errors"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -22,6 +25,14 @@ const (
 	OPModeComment   OPMode = "comment"   // OPModeComment is the comment operational mode.
 	OPModeGenerate  OPMode = "generate"  // OPModeGenerate is the generate operational mode.
 	OPModeConfigure OPMode = "configure" // OPModeConfigure is the configure operational mode.
+	OPModeDelete    OPMode = "delete"    // OPModeDelete is the delete operational mode.
+
+	// Commit OPMode constants
+	OPModeCommit        OPMode = "commit"         // OPModeCommit commits the pending configuration.
+	OPModeCommitConfirm OPMode = "commit-confirm" // OPModeCommitConfirm commits with an automatic revert window.
+	OPModeConfirm       OPMode = "confirm"        // OPModeConfirm confirms a pending commit-confirm.
+	OPModeDiscard       OPMode = "discard"        // OPModeDiscard discards uncommitted changes.
+	OPModeRollback      OPMode = "rollback"       // OPModeRollback rolls back to a previous revision.
 )
 
 // Vyos represents a VyOS API client.
@@ -34,6 +45,10 @@ type Client struct {
 
 	Token string // Token used for authentication.
 
+	auth    Authenticator // Authenticator used for requests. Nil sends Token in the key field.
+	encoder Encoder       // Encoder for request bodies. Nil uses MultipartEncoder.
+	retry   *RetryPolicy  // Retry policy for transient failures. Nil disables retries.
+
 	common service // Reuse a single struct instead of allocating one for each service on the heap.
 
 	// Services used for talking to different parts of the VyOS API.
@@ -44,6 +59,10 @@ type Client struct {
 	Power      *PowerService
 	Image      *ImageService
 	ConfigFile *ConfigService
+	Interfaces *InterfacesService
+	Firewall   *FirewallService
+	NAT        *NATService
+	Protocols  *ProtocolsService
 }
 
 // Service represents a VyOS API service.
@@ -118,8 +137,9 @@ func (c *Client) WithToken(token string) *Client {
 	newClient := c.copy()
 	defer newClient.init()
 
-	// Set the token for the API client.
+	// Set the token for the API client. It replaces any authenticator.
 	newClient.Token = token
+	newClient.auth = nil
 
 	return newClient
 }
@@ -129,24 +149,53 @@ func (c *Client) Insecure() *Client {
 	newClient := c.copy()
 	defer newClient.init()
 
-	// Type assertion to ensure transport is of type *http.Transport
-	if t, ok := newClient.client.Transport.(*http.Transport); ok {
-		t.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
-		}
-		newClient.client.Transport = t
-	} else {
-		// Handle the case where the transport is not of type *http.Transport
-		newClient.client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		}
-	}
+	// Set the TLS configuration on a copy of the transport.
+	newClient.client.Transport = tlsTransport(newClient.client.Transport, &tls.Config{
+		InsecureSkipVerify: true,
+	})
 
 	return newClient
 }
 
+// WithTLSConfig sets the TLS configuration for the VyOS API client, e.g. to
+// trust a private CA or present a client certificate.
+func (c *Client) WithTLSConfig(config *tls.Config) *Client {
+	newClient := c.copy()
+	defer newClient.init()
+
+	// Set the TLS configuration on a copy of the transport.
+	newClient.client.Transport = tlsTransport(newClient.client.Transport, config.Clone())
+
+	return newClient
+}
+
+// tlsTransport returns a copy of rt using the TLS configuration. A
+// CassetteTransport keeps wrapping its transport, which gets the
+// configuration. Other custom transports are returned unchanged, as their
+// TLS settings cannot be reached.
+func tlsTransport(rt http.RoundTripper, config *tls.Config) http.RoundTripper {
+
+	switch t := rt.(type) {
+	case nil:
+		t2 := http.DefaultTransport.(*http.Transport).Clone()
+		t2.TLSClientConfig = config
+		return t2
+
+	case *http.Transport:
+		// Clone the transport, which is shared with the original client.
+		t = t.Clone()
+		t.TLSClientConfig = config
+		return t
+
+	case *CassetteTransport:
+		ct := *t
+		ct.Transport = tlsTransport(t.Transport, config)
+		return &ct
+	}
+
+	return rt
+}
+
 // init initializes the VyOS API client.
 func (c *Client) init() {
 
@@ -162,9 +211,14 @@ func (c *Client) init() {
 	c.Gen = (*GenerateService)(&c.common)
 	c.Show = (*ShowService)(&c.common)
 	c.Conf = (*ConfigService)(&c.common)
+	c.ConfigFile = (*ConfigService)(&c.common)
 	c.Power = (*PowerService)(&c.common)
 	c.Image = (*ImageService)(&c.common)
 	c.Reset = (*ResetService)(&c.common)
+	c.Interfaces = (*InterfacesService)(&c.common)
+	c.Firewall = (*FirewallService)(&c.common)
+	c.NAT = (*NATService)(&c.common)
+	c.Protocols = (*ProtocolsService)(&c.common)
 
 }
 
@@ -175,16 +229,34 @@ func (c *Client) copy() *Client {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// Copy the http.Client so its transport, timeout and cookie jar are kept.
+	h := *c.client
+
 	return &Client{
-		client:    &http.Client{},
+		client:    &h,
 		BaseURL:   c.BaseURL,
 		Token:     c.Token,
 		UserAgent: c.UserAgent,
+		auth:      c.auth,
+		retry:     c.retry,
+		encoder:   c.encoder,
 	}
 }
 
-// NewRequest creates a new HTTP request for the VyOS API.
+// NewRequest creates a new HTTP request for the VyOS API. It is equivalent to
+// NewRequestWithContext with context.Background.
 func (c *Client) NewRequest(urlStr string, request interface{}) (*http.Request, error) {
+	return c.NewRequestWithContext(context.Background(), urlStr, request)
+}
+
+// NewRequestWithContext creates a new HTTP request for the VyOS API. The
+// request is authenticated by the client's Authenticator, or the one set on
+// ctx with ContextWithAuthenticator.
+func (c *Client) NewRequestWithContext(ctx context.Context, urlStr string, request interface{}) (*http.Request, error) {
+
+	if ctx == nil {
+		return nil, ErrContextNil
+	}
 
 	// The method must always be POST. The VyOS API only supports POST requests.
 	method := http.MethodPost
@@ -198,34 +270,56 @@ func (c *Client) NewRequest(urlStr string, request interface{}) (*http.Request,
 		return nil, err
 	}
 
-	// Create a bytes.Buffer from the JSON data
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	writer.WriteField("data", string(jsonData))
-	writer.WriteField("key", c.Token)
-	writer.Close()
-
-	req, err := http.NewRequest(method, u, body)
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set the content type to multipart/form-data
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	// Add the credentials, either as form fields or as headers.
+	fields := url.Values{"data": {string(jsonData)}}
+	if err := c.authenticator(ctx).Authenticate(ctx, req, fields); err != nil {
+		return nil, err
+	}
+
+	// Encode the body once, so it is sized correctly and can be re-read.
+	encoder := c.encoder
+	if encoder == nil {
+		encoder = MultipartEncoder
+	}
 
-	// Set the content length header
-	req.Header.Set("Content-Length", fmt.Sprintf("%d", body.Len()))
+	body, contentType, err := encoder.Encode(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	setBody(req, body)
+	req.Header.Set("Content-Type", contentType)
 
 	// Set the user agent if it is provided.
 	if c.UserAgent != "" {
 		req.Header.Set("User-Agent", c.UserAgent)
 	}
 
+	// Remember the operation so Do can report it if the request fails.
+	req = req.WithContext(context.WithValue(req.Context(), opContextKey{}, requestOp(jsonData)))
+
 	return req, nil
 }
 
 // Do sends an API request and returns the API response.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
+	return c.do(ctx, req, v, false)
+}
+
+// doLocked is like Do but holds the client lock during each attempt, so
+// that requests changing the router state are serialized. The lock is not
+// held while waiting between retries, so other calls can proceed.
+func (c *Client) doLocked(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
+	return c.do(ctx, req, v, true)
+}
+
+// do sends req, retrying transient failures, and decodes the response into v.
+func (c *Client) do(ctx context.Context, req *http.Request, v interface{}, locked bool) (*Response, error) {
 
 	// Check if the context is nil. We always need a context - so they can be cancelled.
 	if ctx == nil {
@@ -236,16 +330,59 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 		return nil, ErrInterfaceNil
 	}
 
-	r, err := c.client.Do(req.WithContext(ctx))
-	if err != nil {
+	op, _ := req.Context().Value(opContextKey{}).(OPMode)
+
+	// Only retry requests the policy allows, e.g. /configure is opt-in.
+	policy := c.retry
+	if !policy.allows(req) {
+		policy = nil
+	}
+
+	var (
+		r    *http.Response
+		body []byte
+		err  error
+	)
+
+	for attempt := 1; ; attempt++ {
+
+		if locked {
+			c.mu.Lock()
+		}
+		r, body, err = c.send(ctx, req, op, attempt)
+		if locked {
+			c.mu.Unlock()
+		}
+
+		if err == nil || policy == nil || attempt >= policy.MaxAttempts || !policy.shouldRetry(ctx, r, err) {
+			break
+		}
+
+		// Wait before the next attempt, giving up if the context is done.
+		if errSleep := sleep(ctx, policy.backoff(attempt, r)); errSleep != nil {
+			break
+		}
+	}
+
+	if r == nil {
 		return nil, err
 	}
 
 	resp := &Response{Response: r}
-	defer resp.Body.Close()
+
+	// Return the API error along with whatever the body decodes to.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		json.Unmarshal(body, v)
+		return resp, err
+	}
+
+	if err != nil {
+		return resp, err
+	}
 
 	// Decode the response body into the provided interface.
-	errDecode := json.NewDecoder(resp.Body).Decode(v)
+	errDecode := json.Unmarshal(body, v)
 	if errDecode != nil {
 		return nil, errDecode
 	}
@@ -254,6 +391,125 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 
 }
 
+// send makes a single attempt of req and reads the response body. The body
+// of req is re-created for every attempt after the first.
+func (c *Client) send(ctx context.Context, req *http.Request, op OPMode, attempt int) (*http.Response, []byte, error) {
+
+	if attempt > 1 && req.GetBody != nil {
+		reqBody, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		req = req.Clone(ctx)
+		req.Body = reqBody
+	} else {
+		req = req.WithContext(ctx)
+	}
+
+	r, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return r, nil, err
+	}
+
+	// Check whether the API reported a failure before decoding the body.
+	if err := checkResponse(r, body, op); err != nil {
+		return r, body, err
+	}
+
+	return r, body, nil
+}
+
+// checkResponse returns an *APIError if the response has an HTTP error status
+// or the body explicitly reports `success: false`.
+func checkResponse(r *http.Response, body []byte, op OPMode) error {
+
+	// Only an explicit `success: false` is treated as a failure, so pointers
+	// are used to tell missing fields apart from zero values.
+	var status struct {
+		Success *bool   `json:"success"`
+		Error   *string `json:"error"`
+	}
+	errDecode := json.Unmarshal(body, &status)
+
+	failed := r.StatusCode >= http.StatusBadRequest ||
+		(errDecode == nil && status.Success != nil && !*status.Success)
+	if !failed {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: r.StatusCode,
+		Op:         op,
+	}
+
+	if r.Request != nil && r.Request.URL != nil {
+		apiErr.Path = r.Request.URL.Path
+	}
+
+	switch {
+	case errDecode == nil && status.Error != nil:
+		apiErr.Message = *status.Error
+	case errDecode != nil:
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apiErr
+}
+
+// setBody sets the body of req, allowing it to be re-read for retries.
+func setBody(req *http.Request, body []byte) {
+
+	req.ContentLength = int64(len(body))
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+// sortedFields returns the names of the form fields in a stable order, so
+// `data` is sent before `key`.
+func sortedFields(fields url.Values) []string {
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// opContextKey is the context key used to carry the requested operation
+// from NewRequest to Do.
+type opContextKey struct{}
+
+// requestOp extracts the operation from an encoded request. For batched
+// requests the operation of the first request is returned.
+func requestOp(data []byte) OPMode {
+
+	var single struct {
+		OPMode OPMode `json:"op"`
+	}
+	if err := json.Unmarshal(data, &single); err == nil {
+		return single.OPMode
+	}
+
+	var batch []struct {
+		OPMode OPMode `json:"op"`
+	}
+	if err := json.Unmarshal(data, &batch); err == nil && len(batch) > 0 {
+		return batch[0].OPMode
+	}
+
+	return ""
+}
+
 func (c *Client) Save(ctx context.Context) {
 	c.mu.Lock()
 	c.Conf.Save(ctx, "")
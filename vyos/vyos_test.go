@@ -1,8 +1,22 @@
 package vyos
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // TestNewClient tests the NewClient function.
@@ -20,6 +34,10 @@ func TestNewClient(t *testing.T) {
 		t.Error("NewClient returned same http.Clients, but they should differ")
 	}
 
+	if c.ConfigFile == nil {
+		t.Error("NewClient ConfigFile is nil")
+	}
+
 }
 
 // TestClientCopy tests the copy method of the Client struct.
@@ -33,6 +51,12 @@ func TestClientCopy(t *testing.T) {
 		t.Error("Client copy returned same http.Clients, but they should differ")
 	}
 
+	// The transport, e.g. with TLS settings, must survive further options.
+	c3 := NewClient(nil).Insecure().WithURL("https://test.com").WithToken("test").WithRetry(DefaultRetryPolicy())
+	if _, ok := c3.client.Transport.(*http.Transport); !ok {
+		t.Error("Client copy dropped the Insecure transport")
+	}
+
 }
 
 // TestClientWithURL tests the WithURL method of the Client struct.
@@ -70,3 +94,292 @@ func TestClientInsecure(t *testing.T) {
 	}
 
 }
+
+// TestWithTLSConfig tests that the TLS configuration is set on a copy of the
+// transport.
+func TestWithTLSConfig(t *testing.T) {
+
+	t.Parallel()
+
+	c := NewClient(nil)
+	tc := c.WithTLSConfig(&tls.Config{ServerName: "router.example.com"})
+
+	tr, ok := tc.client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport is %T, want *http.Transport", tc.client.Transport)
+	}
+	if got := tr.TLSClientConfig.ServerName; got != "router.example.com" {
+		t.Errorf("ServerName is %v, want %v", got, "router.example.com")
+	}
+	if c.client.Transport == tc.client.Transport {
+		t.Error("transport is shared with the original client")
+	}
+	if tc.BaseURL != c.BaseURL {
+		t.Errorf("BaseURL is %v, want %v", tc.BaseURL, c.BaseURL)
+	}
+}
+
+// TestDoAPIError tests that failed API calls are returned as *APIError.
+func TestDoAPIError(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		target error
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"success": false, "error": "Valid API key is required", "data": null}`, ErrUnauthorized},
+		{"invalid path", http.StatusBadRequest, `{"success": false, "error": "Configuration path: [interfaces dummy] is not valid", "data": null}`, ErrInvalidPath},
+		{"commit failed", http.StatusOK, `{"success": false, "error": "Commit failed", "data": null}`, ErrCommitFailed},
+		{"locked", http.StatusOK, `{"success": false, "error": "Configuration is locked", "data": null}`, ErrConfigLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := NewClient(nil).WithURL(srv.URL).WithToken("test")
+			_, _, err := c.Conf.Set(context.TODO(), "interfaces dummy dum0")
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Conf.Set returned %v, want *APIError", err)
+			}
+
+			if got, want := apiErr.StatusCode, tt.status; got != want {
+				t.Errorf("APIError StatusCode is %v, want %v", got, want)
+			}
+
+			if got, want := apiErr.Path, "/configure"; got != want {
+				t.Errorf("APIError Path is %v, want %v", got, want)
+			}
+
+			if got, want := apiErr.Op, OPModeSet; got != want {
+				t.Errorf("APIError Op is %v, want %v", got, want)
+			}
+
+			if !errors.Is(err, tt.target) {
+				t.Errorf("errors.Is(%v, %v) is false, want true", err, tt.target)
+			}
+		})
+	}
+}
+
+// TestDoRetry tests that transient failures are retried according to the
+// retry policy.
+func TestDoRetry(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		call    func(c *Client) error
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "show retried",
+			policy: RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond},
+			call: func(c *Client) error {
+				_, _, err := c.Show.Do(context.TODO(), "version")
+				return err
+			},
+			want: 3,
+		},
+		{
+			name:   "configure not retried",
+			policy: RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond},
+			call: func(c *Client) error {
+				_, _, err := c.Conf.Set(context.TODO(), "interfaces dummy dum0")
+				return err
+			},
+			want:    1,
+			wantErr: true,
+		},
+		{
+			name:   "configure opt-in",
+			policy: RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, RetryNonIdempotent: true},
+			call: func(c *Client) error {
+				_, _, err := c.Conf.Set(context.TODO(), "interfaces dummy dum0")
+				return err
+			},
+			want: 3,
+		},
+		{
+			name:   "attempts exhausted",
+			policy: RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond},
+			call: func(c *Client) error {
+				_, _, err := c.Show.Do(context.TODO(), "version")
+				return err
+			},
+			want:    2,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var attempts int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+
+				// Every attempt must carry the full request body.
+				if r.FormValue("data") == "" {
+					t.Errorf("attempt %d has no data field", attempts)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				if attempts < 3 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusServiceUnavailable)
+					w.Write([]byte(`{"success": false, "error": "busy", "data": null}`))
+					return
+				}
+				w.Write([]byte(`{"success": true, "error": null, "data": ""}`))
+			}))
+			defer srv.Close()
+
+			c := NewClient(nil).WithURL(srv.URL).WithToken("test").WithRetry(tt.policy)
+			err := tt.call(c)
+
+			if got, want := attempts, tt.want; got != want {
+				t.Errorf("attempts is %v, want %v", got, want)
+			}
+
+			if got, want := err != nil, tt.wantErr; got != want {
+				t.Errorf("error is %v, want error %v", err, want)
+			}
+		})
+	}
+}
+
+// TestRetryBackoff tests the backoff delays of a retry policy.
+func TestRetryBackoff(t *testing.T) {
+
+	t.Parallel()
+	p := &RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	for retry, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		if got := p.backoff(retry+1, nil); got != want {
+			t.Errorf("backoff(%d) is %v, want %v", retry+1, got, want)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	if got, want := p.backoff(1, resp), 3*time.Second; got != want {
+		t.Errorf("backoff with Retry-After is %v, want %v", got, want)
+	}
+
+	p.Jitter = true
+	if got := p.backoff(2, nil); got < time.Second || got > 2*time.Second {
+		t.Errorf("backoff with jitter is %v, want between 1s and 2s", got)
+	}
+}
+
+// TestTransientError tests which errors without a response are retried.
+func TestTransientError(t *testing.T) {
+
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", &url.Error{Op: "Post", URL: "https://r1", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, true},
+		{"connection reset", &url.Error{Op: "Post", URL: "https://r1", Err: syscall.ECONNRESET}, true},
+		{"closed", &url.Error{Op: "Post", URL: "https://r1", Err: io.EOF}, true},
+		{"timeout", &url.Error{Op: "Post", URL: "https://r1", Err: context.DeadlineExceeded}, true},
+		{"dns not found", &url.Error{Op: "Post", URL: "https://r1", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, false},
+		{"tls verification", &url.Error{Op: "Post", URL: "https://r1", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, false},
+		{"bad url", &url.Error{Op: "Post", URL: "ftp://r1", Err: errors.New(`unsupported protocol scheme "ftp"`)}, false},
+	}
+
+	for _, tt := range tests {
+		if got := transientError(tt.err); got != tt.want {
+			t.Errorf("transientError(%s) is %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// An unverifiable certificate fails once instead of being retried.
+	var attempts atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer srv.Close()
+
+	var dials atomic.Int32
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return dial(ctx, network, addr)
+	}
+
+	c := NewClient(&http.Client{Transport: transport}).WithURL(srv.URL).WithToken("test").
+		WithRetry(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond})
+	if _, _, err := c.Show.Do(context.TODO(), "version"); err == nil {
+		t.Fatal("Show.Do returned no error for an untrusted certificate")
+	}
+
+	if got := dials.Load(); got != 1 {
+		t.Errorf("dials are %v, want %v", got, 1)
+	}
+}
+
+// TestRetryReleasesLock tests that a configuration request waiting to retry
+// does not block other configuration requests.
+func TestRetryReleasesLock(t *testing.T) {
+
+	t.Parallel()
+
+	first := make(chan struct{})
+	var once sync.Once
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/configure" {
+			once.Do(func() { close(first) })
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"success": false, "error": "busy", "data": null}`))
+			return
+		}
+		w.Write([]byte(`{"success": true, "error": null, "data": ""}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil).WithURL(srv.URL).WithToken("test").
+		WithRetry(RetryPolicy{MaxAttempts: 2, MinBackoff: 2 * time.Second, RetryNonIdempotent: true})
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Conf.Commit(ctx)
+	}()
+
+	<-first
+	start := time.Now()
+	if _, _, err := c.Conf.Save(context.TODO(), ""); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Save waited %v for the retrying commit", elapsed)
+	}
+
+	// Stop the commit waiting to retry.
+	cancel()
+	<-done
+}
@@ -0,0 +1,99 @@
+package vyostest
+
+import (
+	"github.com/ganawaj/go-vyos/vyos"
+	"github.com/ganawaj/go-vyos/vyos/configtree"
+)
+
+// multiValueLeaves lists the names of common multi-value leaves.
+var multiValueLeaves = map[string]bool{
+	"address": true, "domain-search": true, "interface": true,
+	"name-server": true, "network": true, "port": true,
+}
+
+// DefaultIsTag reports whether the node at path is a tag node, whose
+// children are named by the user, e.g. `interfaces ethernet` or
+// `firewall ipv4 name`. It is configtree.DefaultTagNodes, except for names
+// that are tag nodes in one place and leaves in another.
+func DefaultIsTag(path vyos.Path) bool {
+
+	if len(path) == 0 {
+		return false
+	}
+
+	parent := ""
+	if len(path) > 1 {
+		parent = path[len(path)-2]
+	}
+
+	switch path[len(path)-1] {
+	case "host-name":
+		return parent == "static-host-mapping"
+	case "name":
+		return parent == "ipv4" || parent == "ipv6"
+	case "peer-group":
+		return parent == "bgp"
+	case "network":
+		return parent == "ipv4-unicast" || parent == "ipv6-unicast"
+	}
+
+	return configtree.DefaultTagNodes(path)
+}
+
+// DefaultIsMulti reports whether the leaf at path holds multiple values,
+// such as `address` or `name-server`.
+func DefaultIsMulti(path vyos.Path) bool {
+	return len(path) > 0 && multiValueLeaves[path[len(path)-1]]
+}
+
+// setPath applies a `set` of path to tree. The JSON tree does not record
+// whether the last element of a path is a value or a node, so this is
+// decided with isTag: below a tag node it is a node, e.g. the `eth1` of
+// `interfaces ethernet eth1`, otherwise the element before it is a leaf
+// holding it as a value, e.g. `system host-name r1`.
+//
+// A leaf that turns out to be a node, because a longer path is set through
+// it, is converted to a node.
+func setPath(tree vyos.ConfigTree, path vyos.Path, isTag, isMulti func(vyos.Path) bool) error {
+
+	node := map[string]interface{}(tree)
+
+	for i, name := range path {
+
+		child, ok := node[name]
+
+		// The last element is a node or valueless leaf.
+		if i == len(path)-1 {
+			if !ok {
+				node[name] = map[string]interface{}{}
+			}
+			return nil
+		}
+
+		childNode, isNode := child.(map[string]interface{})
+
+		// The last element is the value of a leaf. Top level nodes are
+		// never leaves.
+		if i == len(path)-2 && i > 0 && !isNode && !isTag(path[:i+1]) {
+			if isMulti(path[:i+1]) {
+				return tree.AddValue(path[:i+1], path[i+1])
+			}
+			return tree.Set(path[:i+1], path[i+1])
+		}
+
+		if !isNode {
+			// Convert a leaf to a node with its values as children.
+			childNode = make(map[string]interface{})
+			if ok {
+				for _, v := range tree.Values(path[:i+1]) {
+					childNode[v] = map[string]interface{}{}
+				}
+			}
+			node[name] = childNode
+		}
+
+		node = childNode
+	}
+
+	return nil
+}
@@ -0,0 +1,765 @@
+// Package vyostest provides a fake VyOS API server for testing code built on
+// go-vyos without a router.
+//
+// The server keeps the configuration in memory and implements the /retrieve,
+// /configure, /show, /generate, /reset, /image, /config-file, /reboot and
+// /poweroff endpoints with the success and error responses of the real API.
+// Requests are accepted in all encodings supported by the client.
+//
+//	srv := vyostest.NewServer()
+//	defer srv.Close()
+//
+//	c := srv.NewClient()
+//	c.Conf.Set(ctx, "interfaces ethernet eth0 address 192.0.2.1/24")
+//
+//	tree := srv.Config()
+package vyostest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ganawaj/go-vyos/vyos"
+)
+
+// DefaultKey is the API key accepted by a new server.
+const DefaultKey = "vyostest"
+
+// Call is a request received by the server.
+type Call struct {
+	Endpoint string          // Endpoint, e.g. /configure.
+	OPMode   vyos.OPMode     // Operation of the request, or of the first request of a batch.
+	Data     json.RawMessage // Decoded `data` field.
+}
+
+// Server is a fake VyOS API server.
+type Server struct {
+	*httptest.Server
+
+	// Key is the API key required for requests. An empty key accepts all
+	// requests.
+	Key string
+
+	// ManualCommit keeps /configure changes in the candidate configuration
+	// until a commit, instead of committing each request as VyOS does.
+	ManualCommit bool
+
+	// RebootDowntime is the number of requests answered with 502 Bad Gateway
+	// after a reboot, simulating the router being down.
+	RebootDowntime int
+
+	// IsTag and IsMulti describe the configuration schema for `set`. They
+	// default to DefaultIsTag and DefaultIsMulti.
+	IsTag   func(path vyos.Path) bool
+	IsMulti func(path vyos.Path) bool
+
+	mu        sync.Mutex
+	running   vyos.ConfigTree
+	candidate vyos.ConfigTree
+	revisions []vyos.ConfigTree // Committed configurations, newest first.
+	confirm   vyos.ConfigTree   // Configuration restored if a commit-confirm expires.
+	comments  map[string]string
+	files     map[string]vyos.ConfigTree
+	outputs   map[string]string
+	images    []vyos.Image
+	scheduled map[string]vyos.Path
+	reboots   int
+	down      int
+	off       bool
+	fail      []failure
+	calls     []Call
+}
+
+// failure is an injected error response.
+type failure struct {
+	status  int
+	message string
+}
+
+// apiRequest is a request in the `data` field.
+type apiRequest struct {
+	OPMode      vyos.OPMode `json:"op"`
+	Path        vyos.Path   `json:"path"`
+	File        string      `json:"file"`
+	URL         string      `json:"url"`
+	Name        string      `json:"name"`
+	ConfirmTime int         `json:"confirm_time"`
+	Revision    int         `json:"revision"`
+}
+
+// apiError is an error response.
+type apiError struct {
+	status  int
+	message string
+}
+
+// NewServer starts and returns a new server with an empty configuration and
+// a single running image. The caller should call Close when finished.
+func NewServer() *Server {
+
+	s := &Server{
+		Key:       DefaultKey,
+		running:   vyos.ConfigTree{},
+		candidate: vyos.ConfigTree{},
+		comments:  make(map[string]string),
+		files:     make(map[string]vyos.ConfigTree),
+		outputs:   make(map[string]string),
+		images:    []vyos.Image{{Name: "1.4.0", Default: true, Running: true}},
+		scheduled: make(map[string]vyos.Path),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// NewClient returns a client for the server using its key.
+func (s *Server) NewClient() *vyos.Client {
+	return vyos.NewClient(s.Client()).WithURL(s.URL).WithToken(s.Key)
+}
+
+// Config returns a copy of the running configuration.
+func (s *Server) Config() vyos.ConfigTree {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running.Copy()
+}
+
+// Candidate returns a copy of the candidate configuration, which includes
+// uncommitted changes when ManualCommit is set.
+func (s *Server) Candidate() vyos.ConfigTree {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.candidate.Copy()
+}
+
+// SetConfig replaces the running and candidate configuration.
+func (s *Server) SetConfig(tree vyos.ConfigTree) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = tree.Copy()
+	s.candidate = tree.Copy()
+}
+
+// Comment returns the comment set on the node at path.
+func (s *Server) Comment(path vyos.Path) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.comments[path.String()]
+}
+
+// File returns the configuration saved to a file with /config-file.
+func (s *Server) File(name string) (vyos.ConfigTree, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tree, ok := s.files[name]
+	return tree.Copy(), ok
+}
+
+// SetOutput sets the output returned by /show, /generate or /reset for
+// path, e.g. SetOutput("/show", "interfaces", "...").
+func (s *Server) SetOutput(endpoint, path, output string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outputs[endpoint+" "+path] = output
+}
+
+// Images returns the installed images.
+func (s *Server) Images() []vyos.Image {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]vyos.Image(nil), s.images...)
+}
+
+// SetImages replaces the installed images.
+func (s *Server) SetImages(images []vyos.Image) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images = append([]vyos.Image(nil), images...)
+}
+
+// Reboots returns the number of reboots.
+func (s *Server) Reboots() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reboots
+}
+
+// PoweredOff reports whether the router was powered off. A powered off
+// server answers all requests with 503 Service Unavailable.
+func (s *Server) PoweredOff() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.off
+}
+
+// Scheduled returns the path of a scheduled reboot or power off, e.g.
+// `in 10` for Scheduled("/reboot").
+func (s *Server) Scheduled(endpoint string) vyos.Path {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scheduled[endpoint]
+}
+
+// Calls returns the requests received by the server.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// FailNext makes the next request fail with status and message, e.g. to
+// test retries. Repeated calls queue failures.
+func (s *Server) FailNext(status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = append(s.fail, failure{status, message})
+}
+
+// ExpireCommitConfirm reverts a pending commit-confirm as if its confirm
+// time had passed, and reports whether one was pending.
+func (s *Server) ExpireCommitConfirm() bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.confirm == nil {
+		return false
+	}
+
+	s.commit(s.confirm)
+	s.confirm = nil
+
+	return true
+}
+
+// serveHTTP handles all requests.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	if s.off {
+		writeError(w, http.StatusServiceUnavailable, "Service Unavailable")
+		return
+	}
+
+	if s.down > 0 {
+		s.down--
+		writeError(w, http.StatusBadGateway, "Bad Gateway")
+		return
+	}
+
+	data, key, err := decodeForm(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var requests []apiRequest
+	if err := decodeData(data, &requests); err != nil {
+		writeError(w, http.StatusBadRequest, "Request is not valid JSON")
+		return
+	}
+
+	call := Call{Endpoint: r.URL.Path, Data: data}
+	if len(requests) > 0 {
+		call.OPMode = requests[0].OPMode
+	}
+	s.calls = append(s.calls, call)
+
+	if len(s.fail) > 0 {
+		f := s.fail[0]
+		s.fail = s.fail[1:]
+		writeError(w, f.status, f.message)
+		return
+	}
+
+	if s.Key != "" && key != s.Key {
+		writeError(w, http.StatusUnauthorized, "Valid API key is required")
+		return
+	}
+
+	if len(requests) == 0 {
+		writeError(w, http.StatusBadRequest, "Request is empty")
+		return
+	}
+
+	var out interface{}
+	var apiErr *apiError
+
+	switch r.URL.Path {
+	case "/retrieve":
+		out, apiErr = s.retrieve(requests[0])
+	case "/configure":
+		out, apiErr = s.configure(requests)
+	case "/show", "/generate", "/reset":
+		out, apiErr = s.output(r.URL.Path, requests[0])
+	case "/image":
+		out, apiErr = s.image(requests[0])
+	case "/config-file":
+		out, apiErr = s.configFile(requests[0])
+	case "/reboot", "/poweroff":
+		out, apiErr = s.power(r.URL.Path, requests[0])
+	default:
+		apiErr = &apiError{http.StatusNotFound, "Not Found"}
+	}
+
+	if apiErr != nil {
+		writeError(w, apiErr.status, apiErr.message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "data": out, "error": nil})
+}
+
+// retrieve handles /retrieve.
+func (s *Server) retrieve(req apiRequest) (interface{}, *apiError) {
+
+	switch req.OPMode {
+	case "exists":
+		return s.candidate.Exists(req.Path), nil
+
+	case "showConfig":
+		if len(req.Path) == 0 {
+			return s.candidate.Copy(), nil
+		}
+		v, ok := s.candidate.Get(req.Path)
+		if !ok {
+			return nil, &apiError{http.StatusBadRequest, "Configuration under specified path is empty"}
+		}
+		if node, isNode := v.(map[string]interface{}); isNode {
+			return vyos.ConfigTree(node).Copy(), nil
+		}
+		return v, nil
+
+	case "returnValue":
+		values := s.candidate.Values(req.Path)
+		if len(values) == 0 {
+			return nil, &apiError{http.StatusBadRequest, "Configuration under specified path is empty"}
+		}
+		return values[0], nil
+
+	case "returnValues":
+		values := s.candidate.Values(req.Path)
+		if values == nil {
+			values = []string{}
+		}
+		return values, nil
+	}
+
+	return nil, invalidOp(req.OPMode)
+}
+
+// configure handles /configure. Set, delete and comment requests are
+// applied in order to a copy of the candidate, so a failing batch changes
+// nothing.
+func (s *Server) configure(requests []apiRequest) (interface{}, *apiError) {
+
+	candidate := s.candidate.Copy()
+	comments := make(map[string]string)
+	changed := false
+
+	isTag, isMulti := s.IsTag, s.IsMulti
+	if isTag == nil {
+		isTag = DefaultIsTag
+	}
+	if isMulti == nil {
+		isMulti = DefaultIsMulti
+	}
+
+	for _, req := range requests {
+
+		switch req.OPMode {
+		case vyos.OPModeSet:
+			if len(req.Path) == 0 {
+				return nil, &apiError{http.StatusBadRequest, "Configuration path is empty"}
+			}
+			if err := setPath(candidate, req.Path, isTag, isMulti); err != nil {
+				return nil, invalidPath(req.Path)
+			}
+			changed = true
+
+		case vyos.OPModeDelete:
+			if !candidate.Delete(req.Path) {
+				return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Nothing to delete (the specified node [%s] does not exist)", req.Path)}
+			}
+			changed = true
+
+		case vyos.OPModeComment:
+			if len(req.Path) < 2 || !candidate.Exists(req.Path[:len(req.Path)-1]) {
+				return nil, invalidPath(req.Path)
+			}
+			comments[req.Path[:len(req.Path)-1].String()] = req.Path[len(req.Path)-1]
+
+		case vyos.OPModeCommit, vyos.OPModeCommitConfirm, vyos.OPModeConfirm,
+			vyos.OPModeDiscard, vyos.OPModeRollback:
+			if len(requests) > 1 {
+				return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Operation %q can not be batched", req.OPMode)}
+			}
+			return s.session(req)
+
+		default:
+			return nil, invalidOp(req.OPMode)
+		}
+	}
+
+	s.candidate = candidate
+	for k, v := range comments {
+		s.comments[k] = v
+	}
+
+	if changed && !s.ManualCommit {
+		s.commit(s.candidate)
+	}
+
+	return nil, nil
+}
+
+// session handles the commit, commit-confirm, confirm, discard and rollback
+// operations of /configure.
+func (s *Server) session(req apiRequest) (interface{}, *apiError) {
+
+	switch req.OPMode {
+	case vyos.OPModeCommit:
+		s.commit(s.candidate)
+
+	case vyos.OPModeCommitConfirm:
+		if req.ConfirmTime <= 0 {
+			return nil, &apiError{http.StatusBadRequest, "Confirm time must be greater than zero"}
+		}
+		s.confirm = s.running.Copy()
+		s.commit(s.candidate)
+		return fmt.Sprintf("Initialized commit-confirm; %d minutes to confirm before reboot", req.ConfirmTime), nil
+
+	case vyos.OPModeConfirm:
+		if s.confirm == nil {
+			return nil, &apiError{http.StatusBadRequest, "No confirm pending"}
+		}
+		s.confirm = nil
+
+	case vyos.OPModeDiscard:
+		s.candidate = s.running.Copy()
+
+	case vyos.OPModeRollback:
+		if req.Revision < 0 || req.Revision >= len(s.revisions) {
+			return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Revision %d does not exist", req.Revision)}
+		}
+		s.commit(s.revisions[req.Revision])
+	}
+
+	return nil, nil
+}
+
+// commit makes tree the running configuration.
+func (s *Server) commit(tree vyos.ConfigTree) {
+	s.running = tree.Copy()
+	s.candidate = tree.Copy()
+	s.revisions = append([]vyos.ConfigTree{s.running.Copy()}, s.revisions...)
+}
+
+// output handles /show, /generate and /reset.
+func (s *Server) output(endpoint string, req apiRequest) (interface{}, *apiError) {
+
+	want := map[string]vyos.OPMode{"/show": vyos.OPModeShow, "/generate": vyos.OPModeGenerate, "/reset": "reset"}[endpoint]
+	if req.OPMode != want {
+		return nil, invalidOp(req.OPMode)
+	}
+
+	if len(req.Path) == 0 {
+		return nil, &apiError{http.StatusBadRequest, "Path is empty"}
+	}
+
+	if out, ok := s.outputs[endpoint+" "+req.Path.String()]; ok {
+		return out, nil
+	}
+
+	if endpoint == "/show" {
+		switch req.Path.String() {
+		case "version":
+			return s.version(), nil
+		case "system image":
+			return s.showImages(), nil
+		case "configuration commands":
+			return strings.Join(s.running.Commands(), "\n"), nil
+		}
+		return nil, invalidPath(req.Path)
+	}
+
+	return "", nil
+}
+
+// image handles /image.
+func (s *Server) image(req apiRequest) (interface{}, *apiError) {
+
+	switch req.OPMode {
+	case "add":
+		if req.URL == "" {
+			return nil, &apiError{http.StatusBadRequest, "Missing required field \"url\""}
+		}
+		name := imageName(req.URL)
+		if s.findImage(name) >= 0 {
+			return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Image %s is already installed", name)}
+		}
+		s.images = append([]vyos.Image{{Name: name}}, s.images...)
+		return fmt.Sprintf("Image %s was installed", name), nil
+
+	case vyos.OPModeDelete:
+		i := s.findImage(req.Name)
+		switch {
+		case i < 0:
+			return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Image %s does not exist", req.Name)}
+		case s.images[i].Running:
+			return nil, &apiError{http.StatusBadRequest, "Cannot delete the running image"}
+		}
+		s.images = append(s.images[:i], s.images[i+1:]...)
+		return fmt.Sprintf("Image %s was deleted", req.Name), nil
+
+	case "set_default":
+		i := s.findImage(req.Name)
+		if i < 0 {
+			return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("Image %s does not exist", req.Name)}
+		}
+		for j := range s.images {
+			s.images[j].Default = j == i
+		}
+		return fmt.Sprintf("Default boot image has been switched to %s", req.Name), nil
+	}
+
+	return nil, invalidOp(req.OPMode)
+}
+
+// configFile handles /config-file.
+func (s *Server) configFile(req apiRequest) (interface{}, *apiError) {
+
+	file := req.File
+	if file == "" {
+		file = "/config/config.boot"
+	}
+
+	switch req.OPMode {
+	case "save":
+		s.files[file] = s.running.Copy()
+		return fmt.Sprintf("Saving configuration to '%s'...\nDone", file), nil
+
+	case "load":
+		tree, ok := s.files[file]
+		if !ok {
+			return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("File %s does not exist", file)}
+		}
+		s.commit(tree)
+		return nil, nil
+	}
+
+	return nil, invalidOp(req.OPMode)
+}
+
+// power handles /reboot and /poweroff.
+func (s *Server) power(endpoint string, req apiRequest) (interface{}, *apiError) {
+
+	if want := vyos.OPMode(strings.TrimPrefix(endpoint, "/")); req.OPMode != want {
+		return nil, invalidOp(req.OPMode)
+	}
+
+	if len(req.Path) == 0 {
+		return nil, &apiError{http.StatusBadRequest, "Path is empty"}
+	}
+
+	switch req.Path[0] {
+	case "now":
+		delete(s.scheduled, endpoint)
+		if endpoint == "/poweroff" {
+			s.off = true
+			return nil, nil
+		}
+		s.reboot()
+
+	case "in":
+		if len(req.Path) != 2 {
+			return nil, invalidPath(req.Path)
+		}
+		if minutes, err := strconv.Atoi(req.Path[1]); err != nil || minutes < 1 {
+			return nil, invalidPath(req.Path)
+		}
+		s.scheduled[endpoint] = append(vyos.Path(nil), req.Path...)
+
+	case "at":
+		if len(req.Path) != 2 && len(req.Path) != 4 {
+			return nil, invalidPath(req.Path)
+		}
+		s.scheduled[endpoint] = append(vyos.Path(nil), req.Path...)
+
+	case "cancel":
+		if _, ok := s.scheduled[endpoint]; !ok {
+			return nil, &apiError{http.StatusBadRequest, "No scheduled operation to cancel"}
+		}
+		delete(s.scheduled, endpoint)
+
+	default:
+		return nil, invalidPath(req.Path)
+	}
+
+	return nil, nil
+}
+
+// reboot boots the default image and discards uncommitted changes.
+func (s *Server) reboot() {
+
+	for i := range s.images {
+		s.images[i].Running = s.images[i].Default
+	}
+
+	s.candidate = s.running.Copy()
+	s.confirm = nil
+	s.reboots++
+	s.down = s.RebootDowntime
+}
+
+// version returns the output of `show version`.
+func (s *Server) version() string {
+
+	version := ""
+	for _, img := range s.images {
+		if img.Running {
+			version = img.Name
+		}
+	}
+
+	return fmt.Sprintf("Version:          VyOS %s\nRelease train:    current\n", version)
+}
+
+// showImages returns the output of `show system image`.
+func (s *Server) showImages() string {
+
+	var b strings.Builder
+	b.WriteString("The system currently has the following image(s) installed:\n\n")
+
+	for i, img := range s.images {
+		fmt.Fprintf(&b, "   %d: %s", i+1, img.Name)
+		if img.Default {
+			b.WriteString(" (default boot)")
+		}
+		if img.Running {
+			b.WriteString(" (running image)")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// findImage returns the index of the image with name, or -1.
+func (s *Server) findImage(name string) int {
+	for i, img := range s.images {
+		if img.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// imageName derives the image name from its URL, e.g. 1.4.0 for
+// https://example.com/vyos-1.4.0-generic-amd64.iso.
+func imageName(url string) string {
+
+	name := strings.TrimSuffix(path.Base(url), ".iso")
+	name = strings.TrimPrefix(name, "vyos-")
+	name = strings.TrimSuffix(name, "-amd64")
+	name = strings.TrimSuffix(name, "-generic")
+
+	return name
+}
+
+// decodeForm returns the `data` and `key` fields of a request in any of the
+// encodings supported by the client. The key of a JSON request is removed
+// from the data.
+func decodeForm(r *http.Request) (json.RawMessage, string, error) {
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "application/json":
+		var obj map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			return nil, "", err
+		}
+
+		var key string
+		json.Unmarshal(obj["key"], &key)
+		delete(obj, "key")
+
+		if commands, ok := obj["commands"]; ok {
+			return commands, key, nil
+		}
+
+		data, err := json.Marshal(obj)
+		return data, key, err
+
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			return nil, "", err
+		}
+
+	default:
+		if err := r.ParseForm(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return json.RawMessage(r.FormValue("data")), r.FormValue("key"), nil
+}
+
+// decodeData decodes a single request or a batch.
+func decodeData(data json.RawMessage, requests *[]apiRequest) error {
+
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "" {
+		return nil
+	}
+
+	if trimmed[0] == '[' {
+		return json.Unmarshal(data, requests)
+	}
+
+	var req apiRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	*requests = []apiRequest{req}
+
+	return nil
+}
+
+// invalidOp returns the error for an unsupported operation.
+func invalidOp(op vyos.OPMode) *apiError {
+	return &apiError{http.StatusBadRequest, fmt.Sprintf("\"%s\" is not a valid operation", op)}
+}
+
+// invalidPath returns the error for an invalid path.
+func invalidPath(path vyos.Path) *apiError {
+	return &apiError{http.StatusBadRequest, fmt.Sprintf("Configuration path: [%s] is not valid", path)}
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"success": false, "data": nil, "error": message})
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,216 @@
+package vyostest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganawaj/go-vyos/vyos"
+)
+
+// TestServerConfigure tests set, delete and comment against the server.
+func TestServerConfigure(t *testing.T) {
+
+	t.Parallel()
+	srv := NewServer()
+	defer srv.Close()
+
+	ctx := context.TODO()
+	c := srv.NewClient()
+
+	_, _, err := c.Conf.Set(ctx,
+		"interfaces ethernet eth0 address 192.0.2.1/24",
+		"interfaces ethernet eth0 address 192.0.2.2/24",
+		"interfaces ethernet eth0 description 'Uplink to ISP'",
+		"interfaces ethernet eth1",
+		"system host-name r1",
+		"service ssh port 22",
+	)
+	if err != nil {
+		t.Fatalf("Conf.Set returned error: %v", err)
+	}
+
+	if _, _, err := c.Conf.DeletePath(ctx, vyos.P("interfaces", "ethernet", "eth0", "address", "192.0.2.2/24")); err != nil {
+		t.Fatalf("Conf.DeletePath returned error: %v", err)
+	}
+
+	if _, _, err := c.Conf.CommentPath(ctx, vyos.P("interfaces", "ethernet", "eth0", "WAN")); err != nil {
+		t.Fatalf("Conf.CommentPath returned error: %v", err)
+	}
+
+	want := vyos.ConfigTree{
+		"interfaces": map[string]interface{}{
+			"ethernet": map[string]interface{}{
+				"eth0": map[string]interface{}{
+					"address":     "192.0.2.1/24",
+					"description": "Uplink to ISP",
+				},
+				"eth1": map[string]interface{}{},
+			},
+		},
+		"system":  map[string]interface{}{"host-name": "r1"},
+		"service": map[string]interface{}{"ssh": map[string]interface{}{"port": "22"}},
+	}
+
+	out, _, err := c.Conf.Get(ctx, "", nil)
+	if err != nil {
+		t.Fatalf("Conf.Get returned error: %v", err)
+	}
+
+	got, err := out.Tree()
+	if err != nil {
+		t.Fatalf("Tree returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("configuration is %v, want %v", got, want)
+	}
+
+	if got, want := srv.Comment(vyos.P("interfaces", "ethernet", "eth0")), "WAN"; got != want {
+		t.Errorf("Comment is %v, want %v", got, want)
+	}
+
+	_, _, err = c.Conf.Delete(ctx, "interfaces ethernet eth9")
+	if !errors.Is(err, vyos.ErrInvalidPath) {
+		t.Errorf("Conf.Delete returned %v, want %v", err, vyos.ErrInvalidPath)
+	}
+}
+
+// TestServerCommit tests manual commits, commit-confirm and rollback.
+func TestServerCommit(t *testing.T) {
+
+	t.Parallel()
+	srv := NewServer()
+	srv.ManualCommit = true
+	defer srv.Close()
+
+	ctx := context.TODO()
+	c := srv.NewClient()
+
+	c.Conf.Set(ctx, "system host-name r1")
+	if srv.Config().Exists(vyos.P("system")) {
+		t.Error("configuration was committed without commit")
+	}
+
+	if _, _, err := c.Conf.Commit(ctx); err != nil {
+		t.Fatalf("Conf.Commit returned error: %v", err)
+	}
+
+	c.Conf.Set(ctx, "system host-name r2")
+	if _, _, err := c.Conf.CommitConfirm(ctx, 5); err != nil {
+		t.Fatalf("Conf.CommitConfirm returned error: %v", err)
+	}
+
+	if !srv.ExpireCommitConfirm() {
+		t.Fatal("ExpireCommitConfirm is false, want true")
+	}
+
+	if got, want := srv.Config().Values(vyos.P("system", "host-name")), []string{"r1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("host-name after expired commit-confirm is %v, want %v", got, want)
+	}
+
+	c.Conf.Set(ctx, "system host-name r3")
+	c.Conf.Discard(ctx)
+	if got := srv.Candidate().Values(vyos.P("system", "host-name")); !reflect.DeepEqual(got, []string{"r1"}) {
+		t.Errorf("host-name after discard is %v, want [r1]", got)
+	}
+
+	// Revision 1 is the configuration before the revert.
+	if _, _, err := c.Conf.Rollback(ctx, 1); err != nil {
+		t.Fatalf("Conf.Rollback returned error: %v", err)
+	}
+
+	if got := srv.Config().Values(vyos.P("system", "host-name")); !reflect.DeepEqual(got, []string{"r2"}) {
+		t.Errorf("host-name after rollback is %v, want [r2]", got)
+	}
+}
+
+// TestServerEncodings tests that all client encodings are understood.
+func TestServerEncodings(t *testing.T) {
+
+	t.Parallel()
+
+	for _, encoder := range []vyos.Encoder{vyos.MultipartEncoder, vyos.FormEncoder, vyos.JSONEncoder} {
+
+		srv := NewServer()
+		c := srv.NewClient().WithEncoder(encoder)
+
+		if _, _, err := c.Conf.Set(context.TODO(), "system host-name r1", "system domain-name example.com"); err != nil {
+			t.Errorf("Conf.Set returned error: %v", err)
+		}
+
+		out, _, err := c.Conf.Exists(context.TODO(), "system domain-name")
+		if err != nil {
+			t.Errorf("Conf.Exists returned error: %v", err)
+		} else if out.Data != true {
+			t.Errorf("Conf.Exists is %v, want true", out.Data)
+		}
+
+		srv.Close()
+	}
+}
+
+// TestServerAuth tests that requests with a wrong key are rejected.
+func TestServerAuth(t *testing.T) {
+
+	t.Parallel()
+	srv := NewServer()
+	defer srv.Close()
+
+	c := srv.NewClient().WithToken("wrong")
+	if _, _, err := c.Show.Do(context.TODO(), "version"); !errors.Is(err, vyos.ErrUnauthorized) {
+		t.Errorf("Show.Do returned %v, want %v", err, vyos.ErrUnauthorized)
+	}
+}
+
+// TestServerImages tests the image and power endpoints used by Upgrade.
+func TestServerImages(t *testing.T) {
+
+	t.Parallel()
+	srv := NewServer()
+	srv.SetImages([]vyos.Image{{Name: "1.3.2", Default: true, Running: true}})
+	srv.RebootDowntime = 1
+	defer srv.Close()
+
+	result, err := srv.NewClient().Upgrade(context.TODO(), "https://example.com/vyos-1.4.0-generic-amd64.iso", &vyos.UpgradeOptions{
+		ExpectedVersion: "1.4.0",
+		PollInterval:    time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Upgrade returned error: %v", err)
+	}
+
+	if got, want := result.Version, "1.4.0"; got != want {
+		t.Errorf("Version is %v, want %v", got, want)
+	}
+
+	if got, want := srv.Reboots(), 1; got != want {
+		t.Errorf("Reboots is %v, want %v", got, want)
+	}
+
+	if _, ok := srv.File("/config/config.boot"); !ok {
+		t.Error("configuration was not saved")
+	}
+}
+
+// TestServerFailNext tests injected failures.
+func TestServerFailNext(t *testing.T) {
+
+	t.Parallel()
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.FailNext(http.StatusServiceUnavailable, "busy")
+
+	c := srv.NewClient().WithRetry(vyos.RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond})
+	if _, _, err := c.Show.Do(context.TODO(), "version"); err != nil {
+		t.Fatalf("Show.Do returned error: %v", err)
+	}
+
+	if got, want := len(srv.Calls()), 2; got != want {
+		t.Errorf("Calls is %v, want %v", got, want)
+	}
+}